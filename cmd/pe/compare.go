@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"pattern-evaluator/pkg/artifact"
+	"pattern-evaluator/pkg/chart"
+	"pattern-evaluator/pkg/evaluate"
+	"pattern-evaluator/pkg/pipeline"
+	"pattern-evaluator/pkg/runs"
+	"strings"
+)
+
+var compareImages = []string{"balanced", "worst"}
+var compareKeys = []string{"balanced", "worst", "wins"}
+
+// openTable reads a table of a run, returning nil when the run has no such table or it cannot be read
+func openTable(r *runs.Run, fileName string) *evaluate.MetricsTable {
+	table, err := decodeTable(filepath.Join(r.Dir, "tables", fileName+".gob"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if artifact.IsCorrupt(err) || artifact.IsVersion(err) {
+		fmt.Printf("skipped: %v\n", err)
+		return nil
+	}
+	if err != nil {
+		panic(err)
+	}
+	return table
+}
+
+// significanceChange describes how the significance of a cell changed, it is empty when it did not change
+func significanceChange(before evaluate.Metrics, after evaluate.Metrics, pBefore float64, pAfter float64, alpha float64) string {
+	wasSignificant := pBefore < alpha
+	isSignificant := pAfter < alpha
+	switch {
+	case !wasSignificant && isSignificant:
+		return "gained"
+	case wasSignificant && !isSignificant:
+		return "lost"
+	case wasSignificant && isSignificant && (before.Value() > 0.5) != (after.Value() > 0.5):
+		return "reversed"
+	}
+	return ""
+}
+
+// CompareTable writes the delta heatmaps and csv files of a table between two runs and appends the cells whose
+// significance changed to the summary, it returns the number of compared and changed cells
+func CompareTable(delta *evaluate.MetricsTable, outputDir string, fileName string, alpha float64, summary *strings.Builder) (int, int) {
+
+	title := strings.ReplaceAll(fileName, "_", " ") + " delta"
+	for _, key := range compareImages {
+		img := chart.Heatmap(title, delta, key)
+		chart.SavePNG(img, filepath.Join(outputDir, "png", key, fileName+".png"))
+	}
+	for _, key := range compareKeys {
+		outPath := filepath.Join(outputDir, "csv", key, fileName+".csv")
+		evaluate.DumpMetrics(delta.Values, key, outPath, delta.Rows, delta.Columns)
+	}
+
+	compared, changed := 0, 0
+	for i, row := range delta.Values {
+		for j, cell := range row {
+			if cell == nil {
+				continue
+			}
+			compared++
+			d := cell.(evaluate.DiffMetrics)
+			pBefore, pAfter := evaluate.PValue(d.Base), evaluate.PValue(d.Data)
+			change := significanceChange(d.Base, d.Data, pBefore, pAfter, alpha)
+			if change == "" {
+				continue
+			}
+			changed++
+			summary.WriteString(fmt.Sprintf("%s,%s,%s,%.2f,%.2f,%.2f,%.4f,%.4f,%s\n", fileName, delta.Rows[i], delta.Columns[j],
+				d.Base.Value()*100, d.Data.Value()*100, d.Value()*100, pBefore, pAfter, change))
+		}
+	}
+	return compared, changed
+}
+
+func runCompare(g *Globals, args []string) {
+
+	fs := newFlagSet(g, "compare")
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage of pe compare:\n")
+		fmt.Fprintf(out, "  pe compare [flags] <before> [after]\n\n")
+		fmt.Fprintf(out, "Compares the tables of two runs, after defaults to the latest run. The deltas are written to\n")
+		fmt.Fprintf(out, "compare/<before> in the after run.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	alpha := fs.Float64("alpha", 0.05, "p-value below which the win rate of a cell is significant")
+	parseFlags(g, fs, args)
+
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	afterRef := runs.Latest
+	if fs.NArg() == 2 {
+		afterRef = fs.Arg(1)
+	}
+	before, err := runs.Open(g.Runs, fs.Arg(0))
+	if err != nil {
+		panic(err)
+	}
+	after, err := runs.Open(g.Runs, afterRef)
+	if err != nil {
+		panic(err)
+	}
+	if before.ID == after.ID {
+		fmt.Printf("both refer to run %s, there is nothing to compare\n", before.ID)
+		os.Exit(1)
+	}
+
+	outputDir := filepath.Join(after.Dir, "compare", before.ID)
+	for kind, keys := range map[string][]string{"png": compareImages, "csv": compareKeys} {
+		for _, key := range keys {
+			if err = os.MkdirAll(filepath.Join(outputDir, kind, key), 0755); err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	var summary strings.Builder
+	summary.WriteString("table,row,column,before,after,delta,before_p,after_p,change\n")
+
+	tables, missing, cells, changed := 0, 0, 0, 0
+	pivots := g.LoadPivots()
+	for _, algoName := range g.LoadUniverse().Algorithms {
+		for _, ev := range evaluators() {
+			for _, name := range pipeline.TableNames(pivots) {
+				fileName := tableName(name, ev, algoName)
+				tableBefore := openTable(before, fileName)
+				tableAfter := openTable(after, fileName)
+				if tableBefore == nil || tableAfter == nil {
+					missing++
+					continue
+				}
+				delta := evaluate.CompareMetricsTables(tableAfter, tableBefore)
+				if len(delta.Rows) == 0 || len(delta.Columns) == 0 {
+					fmt.Printf("%s: no rows and columns in common\n", fileName)
+					continue
+				}
+				compared, c := CompareTable(delta, outputDir, fileName, *alpha, &summary)
+				fmt.Printf("%s: %d of %d cells changed significance\n", fileName, c, compared)
+				tables++
+				cells += compared
+				changed += c
+			}
+		}
+	}
+
+	writeText(filepath.Join(outputDir, "significance.csv"), summary.String())
+	fmt.Printf("compared %d tables of %s against %s, %d of %d cells changed significance, %d tables missing in either run\n",
+		tables, after.ID, before.ID, changed, cells, missing)
+	fmt.Printf("written to %s\n", outputDir)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/evaluate"
+	"sort"
+	"strings"
+	"time"
+)
+
+func CountForSymbol(g *Globals, grid *config.EvalParams, algoName string, symbols []string) {
+
+	params := grid.ForAlgorithm(algoName)
+
+	startTime := time.Now().UTC().UnixMilli()
+
+	counter := make(map[string]int)
+	for _, symbol := range symbols {
+		scenarios := loadScenarios(g, algoName, symbol)
+		for _, vector := range params.ParamVectors() {
+			for _, scenario := range scenarios {
+				if evaluate.SameParams(scenario.Parameters, vector) {
+					counter[params.ParamNames(vector)] += len(scenario.Events)
+				}
+			}
+
+		}
+
+	}
+
+	elapsed := time.Now().UTC().UnixMilli() - startTime
+	fmt.Printf("[%s] Took %d milliseconds\n", algoName, elapsed)
+
+	entries := make([]string, 0)
+	for f, i := range counter {
+		entries = append(entries, fmt.Sprintf("%s: %d", f, i))
+	}
+	sort.Strings(entries)
+	fmt.Println("{", strings.Join(entries, ", "), "}")
+
+}
+
+func runCount(g *Globals, args []string) {
+
+	fs := newFlagSet(g, "count")
+	algoFilter := fs.String("algo", "", "only count this algorithm")
+	parseFlags(g, fs, args)
+
+	universe, symbols := g.LoadSymbols()
+	grid := g.LoadParams()
+
+	for _, algoName := range universe.Algorithms {
+		if algoName == "random" || !selected(*algoFilter, algoName) {
+			continue
+		}
+		CountForSymbol(g, grid, algoName, symbols)
+	}
+}
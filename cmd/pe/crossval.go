@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"pattern-evaluator/pkg/crossval"
+	"pattern-evaluator/pkg/pipeline"
+	"strings"
+	"time"
+)
+
+const daySeconds = 24 * 60 * 60
+
+func formatDate(ts int64) string {
+	return time.Unix(ts, 0).UTC().Format("2006-01-02")
+}
+
+// writeCrossValidation stores every fold of a cross-validation as a csv file, followed by the aggregated folds
+func writeCrossValidation(report *crossval.Report, key string, outPath string) {
+	var o strings.Builder
+	o.WriteString(fmt.Sprintf("fold,start,end,configuration,purged,embargoed,train_size,train_%s,test_size,test_%s\n", key, key))
+	for _, f := range report.Folds {
+		o.WriteString(fmt.Sprintf("%d,%s,%s,%s,%d,%d,%s,%s\n", f.Fold.Index+1, formatDate(f.Fold.Start), formatDate(f.Fold.End),
+			f.Label, f.Purged, f.Embargoed, formatMetrics(f.Train, key), formatMetrics(f.Test, key)))
+	}
+	o.WriteString(fmt.Sprintf("total,,,,,,%s,%s\n", formatMetrics(report.Train, key), formatMetrics(report.Test, key)))
+	writeText(outPath, o.String())
+}
+
+func runCrossValidation(g *Globals, args []string) {
+
+	fs := newFlagSet(g, "crossval")
+	cv := pipeline.CrossValidation{}
+	fs.IntVar(&cv.Folds, "folds", 5, "number of consecutive folds to split the events into by time")
+	embargo := fs.Int64("embargo", 5, "days after the labels of a test fold during which events are not trained on")
+	fs.BoolVar(&cv.Forward, "forward", false, "only train on the folds before the test fold")
+	fs.StringVar(&cv.Key, "key", "balanced", "metric to pick the best configuration by")
+	fs.IntVar(&cv.MinSize, "min-size", 50, "fewest training trades for a configuration to be picked")
+	threshold := fs.Float64("threshold", 0, "only evaluate this barrier threshold, 0 evaluates every threshold")
+	timeout := fs.Int64("timeout", 14, "only evaluate this time limit, 0 evaluates every time limit")
+	evaluatorFilter := fs.String("evaluator", "", "only evaluate this evaluator")
+	algoFilter := fs.String("algo", "", "only evaluate this algorithm")
+	parseFlags(g, fs, args)
+	cv.Embargo = *embargo * daySeconds
+
+	universe, symbols := g.LoadSymbols()
+	grid := g.LoadParams()
+	if *threshold != 0 {
+		grid.Thresholds = []float64{*threshold}
+	}
+	if *timeout != 0 {
+		grid.TimeLimits = []int64{*timeout}
+	}
+
+	outputDir := g.Dir("crossval")
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("evaluator,algorithm,folds,train_size,train_%s,test_size,test_%s\n", cv.Key, cv.Key))
+	for _, algoName := range universe.Algorithms {
+		if !selected(*algoFilter, algoName) {
+			continue
+		}
+		events := make(pipeline.Events)
+		for _, symbol := range symbols {
+			if scenarios := loadScenarios(g, algoName, symbol); scenarios != nil {
+				events[symbol] = scenarios
+			}
+		}
+		for _, ev := range evaluators() {
+			if !selected(*evaluatorFilter, ev) {
+				continue
+			}
+			name := ev + "_" + algoName
+			opts := g.Options()
+			opts.Universe = universe
+			report, err := pipeline.CrossValidate(algoName, ev, events, grid, cv, opts)
+			if err != nil {
+				fmt.Printf("skipped: %s: %v\n", name, err)
+				continue
+			}
+			writeCrossValidation(report, cv.Key, filepath.Join(outputDir, name+".csv"))
+			summary.WriteString(fmt.Sprintf("%s,%s,%d,%s,%s\n", ev, algoName, len(report.Folds),
+				formatMetrics(report.Train, cv.Key), formatMetrics(report.Test, cv.Key)))
+			fmt.Printf("%s: train %s, test %s over %d folds\n", name,
+				formatMetrics(report.Train, cv.Key), formatMetrics(report.Test, cv.Key), len(report.Folds))
+		}
+	}
+	writeText(filepath.Join(outputDir, "summary.csv"), summary.String())
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/gob"
+	"path/filepath"
+	"pattern-evaluator/pkg/artifact"
+	"pattern-evaluator/pkg/bucket"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/evaluate"
+	"pattern-evaluator/pkg/manifest"
+	"pattern-evaluator/pkg/pipeline"
+	"pattern-evaluator/pkg/trendscan"
+	"pattern-evaluator/pkg/triplebarrier"
+	"strings"
+)
+
+func decodeCube(filePath string) (*evaluate.MetricsCube, error) {
+	var cube evaluate.MetricsCube
+	gob.Register(triplebarrier.BarrierMetrics{})
+	gob.Register(bucket.BucketMetrics{})
+	gob.Register(trendscan.TrendMetrics{})
+	err := artifact.ReadGob(filePath, artifact.KindCube, &cube)
+	if err != nil {
+		return nil, err
+	}
+	return &cube, nil
+}
+
+func DistilCube(g *Globals, inputPath string, pivots []config.Pivot) error {
+
+	fileName := filepath.Base(inputPath)
+	fileExt := filepath.Ext(fileName)
+	fileNameNoExt := fileName[0 : len(fileName)-len(fileExt)]
+	outputDir := g.Dir("tables")
+
+	cube, err := decodeCube(inputPath)
+	if err != nil {
+		return err
+	}
+
+	// Cubes are named after the evaluator and the algorithm
+	opts := g.Options()
+	opts.Pivots = pivots
+	tables, err := pipeline.Distil(strings.Split(fileNameNoExt, "_")[1], cube, opts)
+	if err != nil {
+		return err
+	}
+	for prefix, table := range tables {
+		err = artifact.WriteGob(filepath.Join(outputDir, prefix+"_"+fileNameNoExt+".gob"), artifact.KindTable, table)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return nil
+}
+
+// distilTargets are the tables of every algorithm and evaluator, queried from their cube
+func distilTargets(g *Globals, universe *config.Universe, pivots []config.Pivot) []Target {
+	targets := make([]Target, 0)
+	for _, algoName := range universe.Algorithms {
+		for _, ev := range evaluators() {
+			a, e := algoName, ev
+			cube := cubePath(g, e, a)
+			outputs := make([]string, 0)
+			for _, name := range pipeline.TableNames(pivots) {
+				outputs = append(outputs, tablePath(g, name, e, a))
+			}
+			targets = append(targets, Target{
+				Name:    "tables " + e + " " + a,
+				Outputs: outputs,
+				Sources: []string{cube},
+				Inputs: manifest.Inputs{
+					"cube":   g.hashOutputs([]string{cube}),
+					"pivots": manifest.HashValue(pivots),
+					"code":   manifest.CodeVersion(),
+				},
+				Build: func() error {
+					return DistilCube(g, cube, pivots)
+				},
+			})
+		}
+	}
+	return targets
+}
+
+func runDistil(g *Globals, args []string) {
+
+	fs := newFlagSet(g, "distil")
+	parseFlags(g, fs, args)
+
+	g.build("distil", distilTargets(g, g.LoadUniverse(), g.LoadPivots()), 1)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"github.com/godoji/algocore/pkg/algo"
+	"io"
+	"os"
+	"path/filepath"
+	"pattern-evaluator/pkg/artifact"
+	"pattern-evaluator/pkg/techniques"
+	"sort"
+	"strings"
+)
+
+func eventsPath(g *Globals, algoName string, symbol string) string {
+	fileName := algoName + "_" + strings.ReplaceAll(symbol, ":", "_") + ".gob"
+	return filepath.Join(g.Dir("events"), fileName)
+}
+
+func cubePath(g *Globals, evaluator string, algoName string) string {
+	return filepath.Join(g.Dir("cubes"), evaluator+"_"+algoName+".gob")
+}
+
+// tableName names the outputs of a table, without an extension
+func tableName(table string, evaluator string, algoName string) string {
+	return table + "_" + evaluator + "_" + algoName
+}
+
+func tablePath(g *Globals, table string, evaluator string, algoName string) string {
+	return filepath.Join(g.Dir("tables"), tableName(table, evaluator, algoName)+".gob")
+}
+
+// evaluators lists the registered evaluators in a stable order
+func evaluators() []string {
+	xs := techniques.GetTechniques()
+	sort.Strings(xs)
+	return xs
+}
+
+// loadScenarios reads the harvested events of a symbol, returning nil when the symbol was never harvested or when
+// its events cannot be read
+func loadScenarios(g *Globals, algoName string, symbol string) []*algo.ScenarioSet {
+	results := make([]*algo.ScenarioSet, 0)
+	err := artifact.ReadGob(eventsPath(g, algoName, symbol), artifact.KindEvents, &results)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if artifact.IsCorrupt(err) || artifact.IsVersion(err) {
+		fmt.Printf("skipped: %v\n", err)
+		return nil
+	}
+	if err != nil {
+		panic(err)
+	}
+	return results
+}
+
+// writeText replaces a text file through an atomic write
+func writeText(path string, text string) {
+	err := artifact.Write(path, func(w io.Writer) error {
+		_, err := io.WriteString(w, text)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// selected reports whether a name passes a filter flag, an empty filter selects everything
+func selected(filter string, name string) bool {
+	return filter == "" || filter == name
+}
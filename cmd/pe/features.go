@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"github.com/northberg/candlestick"
+	"path/filepath"
+	"pattern-evaluator/pkg/benchmark"
+	"pattern-evaluator/pkg/crossval"
+	"pattern-evaluator/pkg/db"
+	"pattern-evaluator/pkg/evaluate"
+	"pattern-evaluator/pkg/features"
+	"pattern-evaluator/pkg/triplebarrier"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LabelOptions are the barriers by which the extracted events are labelled
+type LabelOptions struct {
+	Threshold float64
+	Timeout   int64
+}
+
+// ExtractForSymbol computes the features of every harvested event of the symbol along with its barrier label
+func ExtractForSymbol(g *Globals, algoName string, symbol string, opts LabelOptions) ([]features.Row, []features.Label) {
+
+	collection := db.GetCandles(candlestick.Interval1d, candlestick.Interval1d, symbol)
+
+	// The same event shows up for every parameter it was harvested with, only extract it once
+	seen := make(map[string]bool)
+	rows := make([]features.Row, 0)
+	labels := make([]features.Label, 0)
+	for _, scenario := range loadScenarios(g, algoName, symbol) {
+		for _, event := range scenario.Events {
+			id := evaluate.EventID(symbol, event)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			values, err := features.Extract(event, candlestick.Interval1d, collection)
+			if err != nil {
+				fmt.Printf("[%s -> %s] %s\n", algoName, id, err)
+				continue
+			}
+			rows = append(rows, features.Row{
+				ID:     id,
+				Symbol: symbol,
+				Time:   event.Time,
+				Values: values,
+			})
+			outcome, profit, elapsed := triplebarrier.Label(event, opts.Threshold, opts.Timeout, candlestick.Interval1d, collection, benchmark.Raw)
+			labels = append(labels, features.Label{
+				ID:      id,
+				Symbol:  symbol,
+				Time:    event.Time,
+				Outcome: outcome.String(),
+				Return:  profit,
+				Elapsed: elapsed,
+			})
+		}
+	}
+	return rows, labels
+}
+
+// FoldOptions split the extracted events into cross-validation folds, there are none when Count is zero
+type FoldOptions struct {
+	Count   int
+	Embargo int64
+	Span    int64
+}
+
+func ExtractForAlgorithm(g *Globals, algoName string, symbols []string, folds FoldOptions, labelOpts LabelOptions) {
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, g.Workers)
+	startTime := time.Now().UTC().UnixMilli()
+
+	rowLock := sync.Mutex{}
+	rows := make([]features.Row, 0)
+	labels := make([]features.Label, 0)
+	for _, symbol := range symbols {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(s string) {
+			defer wg.Done()
+			xs, ys := ExtractForSymbol(g, algoName, s, labelOpts)
+			rowLock.Lock()
+			rows = append(rows, xs...)
+			labels = append(labels, ys...)
+			rowLock.Unlock()
+			<-semaphore
+		}(symbol)
+	}
+	wg.Wait()
+
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].ID < rows[j].ID
+	})
+
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].ID < labels[j].ID
+	})
+
+	outputPath := filepath.Join(g.Dir("features"), algoName+".csv")
+	features.DumpRows(rows, outputPath)
+	features.DumpLabels(labels, filepath.Join(g.Dir("features"), algoName+"_labels.csv"))
+
+	if folds.Count > 0 {
+		times := make([]int64, len(rows))
+		for i, row := range rows {
+			times[i] = row.Time
+		}
+		split, err := crossval.Split(times, folds.Count)
+		if err != nil {
+			fmt.Printf("[%s] no folds: %v\n", algoName, err)
+		} else {
+			scheme := crossval.Scheme{Folds: split, Embargo: folds.Embargo}
+			features.DumpFolds(rows, scheme, folds.Span, filepath.Join(g.Dir("features"), algoName+"_folds.csv"))
+		}
+	}
+
+	elapsed := time.Now().UTC().UnixMilli() - startTime
+	fmt.Printf("[%s] Extracted %d events in %d milliseconds\n", algoName, len(rows), elapsed)
+}
+
+func runFeatures(g *Globals, args []string) {
+
+	fs := newFlagSet(g, "features")
+	algoFilter := fs.String("algo", "", "only extract the events of this algorithm")
+	folds := fs.Int("folds", 0, "also write the role of every event in this many purged cross-validation folds")
+	embargo := fs.Int64("embargo", 5, "days after the labels of a test fold during which events are not trained on")
+	labelOpts := LabelOptions{}
+	fs.Float64Var(&labelOpts.Threshold, "threshold", 0.05, "barrier threshold by which the events are labelled")
+	fs.Int64Var(&labelOpts.Timeout, "timeout", 14, "time limit by which the events are labelled")
+	parseFlags(g, fs, args)
+
+	universe, symbols := g.LoadSymbols()
+	grid := g.LoadParams()
+
+	for _, algoName := range universe.Algorithms {
+		if !selected(*algoFilter, algoName) {
+			continue
+		}
+		// Labels are purged by the longest barrier window, as any of them may be fitted
+		longest := int64(0)
+		for _, t := range grid.ForAlgorithm(algoName).TimeLimits {
+			if t > longest {
+				longest = t
+			}
+		}
+		opts := FoldOptions{Count: *folds, Embargo: *embargo * daySeconds, Span: crossval.LabelSpan(longest, candlestick.Interval1d)}
+		ExtractForAlgorithm(g, algoName, symbols, opts, labelOpts)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"pattern-evaluator/pkg/artifact"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/cusum"
+	"pattern-evaluator/pkg/manifest"
+	"pattern-evaluator/pkg/pipeline"
+	"time"
+)
+
+func HarvestForSymbol(g *Globals, hyperParams *config.EvalParams, algoName string, symbol string) {
+
+	outputPath := eventsPath(g, algoName, symbol)
+
+	startTime := time.Now().UTC().UnixMilli()
+	results, err := pipeline.HarvestSymbol(algoName, symbol, hyperParams, g.Options())
+	if err != nil {
+		panic(err)
+	}
+
+	err = artifact.WriteGob(outputPath, artifact.KindEvents, results)
+	if err != nil {
+		panic(err)
+	}
+
+	elapsed := time.Now().UTC().UnixMilli() - startTime
+	if elapsed < 20 {
+		fmt.Printf("[%s -> %s] Took %d milliseconds\n", algoName, symbol, elapsed)
+	}
+}
+
+// harvestTargets are the events of every algorithm and symbol, which only depend on the parameter vectors of the
+// algorithm and on the candle data
+func harvestTargets(g *Globals, universe *config.Universe, symbols []string, grid *config.EvalParams, algoFilter string) []Target {
+	candles, err := config.GetCandleVersion()
+	if err != nil {
+		panic(err)
+	}
+	inputs := make(map[string]manifest.Inputs)
+	for _, algoName := range universe.Algorithms {
+		inputs[algoName] = manifest.Inputs{
+			"params":  manifest.HashValue(grid.ForAlgorithm(algoName).ParamVectors()),
+			"candles": candles,
+		}
+		// Algorithms sampled locally change along with the code
+		if algoName == cusum.Algorithm {
+			inputs[algoName]["code"] = manifest.CodeVersion()
+		}
+	}
+
+	targets := make([]Target, 0)
+	for _, symbol := range symbols {
+		for _, algoName := range universe.Algorithms {
+			if !selected(algoFilter, algoName) {
+				continue
+			}
+			a, s := algoName, symbol
+			targets = append(targets, Target{
+				Name:    "events " + a + " " + s,
+				Outputs: []string{eventsPath(g, a, s)},
+				Inputs:  inputs[a],
+				Build: func() error {
+					HarvestForSymbol(g, grid, a, s)
+					return nil
+				},
+			})
+		}
+	}
+	return targets
+}
+
+func runHarvest(g *Globals, args []string) {
+
+	fs := newFlagSet(g, "harvest")
+	algoFilter := fs.String("algo", "", "only harvest this algorithm")
+	parseFlags(g, fs, args)
+
+	universe, symbols := g.LoadSymbols()
+	hyperParams := g.LoadParams()
+
+	g.build("harvest", harvestTargets(g, universe, symbols, hyperParams, *algoFilter), len(universe.Algorithms))
+}
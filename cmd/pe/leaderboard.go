@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"pattern-evaluator/pkg/artifact"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/evaluate"
+	"pattern-evaluator/pkg/pipeline"
+	"strings"
+)
+
+// defaultConfidence is the confidence level of the intervals on win rates
+const defaultConfidence = 0.95
+
+// openCube reads the cube of an algorithm and evaluator, returning nil when it was never processed or cannot be read
+func openCube(g *Globals, evaluator string, algoName string) *evaluate.MetricsCube {
+	cube, err := decodeCube(cubePath(g, evaluator, algoName))
+	if os.IsNotExist(err) {
+		fmt.Printf("skipped: %s %s was never processed\n", evaluator, algoName)
+		return nil
+	}
+	if artifact.IsCorrupt(err) || artifact.IsVersion(err) {
+		fmt.Printf("skipped: %v\n", err)
+		return nil
+	}
+	if err != nil {
+		panic(err)
+	}
+	return cube
+}
+
+// writeLeaderboards stores the ranking of the symbols in every cell of the cube as a csv file
+func writeLeaderboards(cube *evaluate.MetricsCube, rankings []pipeline.Ranking, key string, outPath string) {
+	var o strings.Builder
+	for _, a := range cube.Axes {
+		if a.Name != config.SymbolDimension {
+			o.WriteString(a.Name + ",")
+		}
+	}
+	o.WriteString(fmt.Sprintf("rank,symbol,size,%s,win_rate_low,win_rate_high\n", key))
+	for _, r := range rankings {
+		cell := strings.Join(r.Labels, ",")
+		for i, s := range r.Standings {
+			o.WriteString(fmt.Sprintf("%s,%d,%s,%d,%.2f,%.2f,%.2f\n", cell, i+1, s.Symbol, s.Size, s.Value, s.Lower, s.Upper))
+		}
+	}
+	writeText(outPath, o.String())
+}
+
+func runLeaderboard(g *Globals, args []string) {
+
+	fs := newFlagSet(g, "leaderboard")
+	key := fs.String("key", "balanced", "metric to rank the symbols by")
+	threshold := fs.Float64("threshold", 0, "barrier threshold to rank, 0 ranks every threshold")
+	timeout := fs.Int64("timeout", 14, "time limit to rank, 0 ranks every time limit")
+	minSize := fs.Int("min-size", 20, "fewest trades for a symbol to be ranked")
+	confidence := fs.Float64("confidence", defaultConfidence, "confidence level of the interval on the win rate")
+	evaluatorFilter := fs.String("evaluator", "", "only rank this evaluator")
+	algoFilter := fs.String("algo", "", "only rank this algorithm")
+	parseFlags(g, fs, args)
+
+	outputDir := g.Dir("leaderboards")
+	for _, algoName := range g.LoadUniverse().Algorithms {
+		if !selected(*algoFilter, algoName) {
+			continue
+		}
+		for _, ev := range evaluators() {
+			if !selected(*evaluatorFilter, ev) {
+				continue
+			}
+			cube := openCube(g, ev, algoName)
+			if cube == nil {
+				continue
+			}
+
+			var err error
+			if *threshold != 0 {
+				if cube, err = cube.Slice(config.ThresholdDimension, fmt.Sprintf("%g", *threshold)); err != nil {
+					fmt.Printf("skipped: %s %s: %v\n", ev, algoName, err)
+					continue
+				}
+			}
+			if *timeout != 0 {
+				if cube, err = cube.Slice(config.TimeoutDimension, fmt.Sprintf("%d", *timeout)); err != nil {
+					fmt.Printf("skipped: %s %s: %v\n", ev, algoName, err)
+					continue
+				}
+			}
+			if cube, err = cube.Flatten(pipeline.ParamAxes(cube), config.ParamsDimension); err != nil {
+				panic(err)
+			}
+
+			rankings, err := pipeline.Leaderboards(cube, *key, *minSize, *confidence)
+			if err != nil {
+				panic(err)
+			}
+			name := ev + "_" + algoName
+			writeLeaderboards(cube, rankings, *key, filepath.Join(outputDir, name+".csv"))
+			fmt.Printf("%s: ranked symbols in %d cells\n", name, len(rankings))
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"pattern-evaluator/pkg/chart"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/manifest"
+	"pattern-evaluator/pkg/pipeline"
+	"pattern-evaluator/pkg/runs"
+	"sync"
+	"time"
+)
+
+// Globals are the flags shared by every subcommand, they may be given before or after the name of the subcommand
+type Globals struct {
+	Runs     string
+	Run      string
+	Params   string
+	Universe string
+	Pivots   string
+	Font     string
+	Workers  int
+	Force    bool
+
+	run          *runs.Run
+	runOnce      sync.Once
+	manifest     *manifest.Manifest
+	manifestOnce sync.Once
+	inputs       runs.Inputs
+}
+
+func newGlobals() *Globals {
+	return &Globals{
+		Runs:     "./runs",
+		Run:      runs.Latest,
+		Params:   "./params.conf",
+		Universe: "./universe.conf",
+		Pivots:   "./pivots.conf",
+		Font:     "./assets/fonts/Helvetica.ttf",
+		Workers:  10,
+	}
+}
+
+// register adds the global flags to a flag set, defaulting to the values that were already parsed
+func (g *Globals) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.Runs, "runs", g.Runs, "directory holding a directory for every run")
+	fs.StringVar(&g.Run, "run", g.Run, "run to read and write, either latest, new, the id of a run or one of its tags")
+	fs.StringVar(&g.Params, "params", g.Params, "parameter grid file")
+	fs.StringVar(&g.Universe, "universe", g.Universe, "universe file with algorithms and symbols")
+	fs.StringVar(&g.Pivots, "pivots", g.Pivots, "pivot file with the tables to distil")
+	fs.StringVar(&g.Font, "font", g.Font, "font used to draw charts")
+	fs.IntVar(&g.Workers, "workers", g.Workers, "number of concurrent workers")
+	fs.BoolVar(&g.Force, "force", g.Force, "rebuild outputs even when their inputs did not change")
+}
+
+// Dir returns a directory below the directory of the run, creating it if needed
+func (g *Globals) Dir(elem ...string) string {
+	dir := filepath.Join(append([]string{g.OpenRun().Dir}, elem...)...)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil && !os.IsExist(err) {
+		panic(err)
+	}
+	return dir
+}
+
+func (g *Globals) LoadUniverse() *config.Universe {
+	universe, err := config.LoadUniverse(g.Universe)
+	if err != nil {
+		panic(err)
+	}
+	g.inputs.Universe = readSnapshot(g.Universe)
+	return universe
+}
+
+// LoadSymbols resolves the universe along with its symbols
+func (g *Globals) LoadSymbols() (*config.Universe, []string) {
+	universe := g.LoadUniverse()
+	symbols, err := universe.Symbols()
+	if err != nil {
+		panic(err)
+	}
+	return universe, symbols
+}
+
+func (g *Globals) LoadParams() *config.EvalParams {
+	params, err := config.LoadEvaluationParameters(g.Params)
+	if err != nil {
+		panic(err)
+	}
+	g.inputs.Params = readSnapshot(g.Params)
+	return params
+}
+
+func (g *Globals) LoadPivots() []config.Pivot {
+	pivots, err := config.LoadPivots(g.Pivots)
+	if err != nil {
+		panic(err)
+	}
+	g.inputs.Pivots = readSnapshot(g.Pivots)
+	return pivots
+}
+
+// Options configures the pipeline stages from the global flags
+func (g *Globals) Options() pipeline.Options {
+	return pipeline.Options{
+		Workers: g.Workers,
+		Logf: func(format string, args ...interface{}) {
+			fmt.Printf(format, args...)
+		},
+	}
+}
+
+type Command struct {
+	Name        string
+	Description string
+	Run         func(g *Globals, args []string)
+}
+
+// The commands are set up in init since their flag sets refer back to this list for usage
+var commands []Command
+
+func init() {
+	commands = []Command{
+		{Name: "harvest", Description: "fetch the events of every algorithm and symbol", Run: runHarvest},
+		{Name: "count", Description: "count the harvested events per algorithm parameter", Run: runCount},
+		{Name: "features", Description: "extract pre-entry features of every harvested event", Run: runFeatures},
+		{Name: "process", Description: "evaluate the harvested events with every evaluator", Run: runProcess},
+		{Name: "distil", Description: "aggregate evaluated metrics into tables", Run: runDistil},
+		{Name: "tap", Description: "render tables as heatmaps and csv files", Run: runTap},
+		{Name: "leaderboard", Description: "rank the symbols within every parameter cell", Run: runLeaderboard},
+		{Name: "rank", Description: "rank the cells of every table across algorithms and evaluators", Run: runRank},
+		{Name: "compare", Description: "compare the tables of two runs", Run: runCompare},
+		{Name: "walkforward", Description: "pick configurations on past years and test them on the year after", Run: runWalkForward},
+		{Name: "crossval", Description: "cross-validate picking configurations over purged and embargoed time folds", Run: runCrossValidation},
+		{Name: "yoy", Description: "break metrics down by calendar period", Run: runYearOverYear},
+		{Name: "validate", Description: "check that random trades do not make money", Run: runValidate},
+		{Name: "runs", Description: "list, start, tag and delete runs", Run: runRuns},
+		{Name: "migrate", Description: "upgrade outputs written in an older format", Run: runMigrate},
+		{Name: "plan", Description: "list the outputs that would be rebuilt by every stage", Run: runPlan},
+		{Name: "survivorship", Description: "report the shift caused by a point-in-time universe", Run: runSurvivorship},
+	}
+}
+
+// newFlagSet prepares the flags of a subcommand, including the global flags
+func newFlagSet(g *Globals, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("pe "+name, flag.ExitOnError)
+	g.register(fs)
+	fs.Usage = func() {
+		for _, c := range commands {
+			if c.Name == name {
+				fmt.Fprintf(fs.Output(), "pe %s: %s\n\n", c.Name, c.Description)
+			}
+		}
+		fmt.Fprintf(fs.Output(), "Usage of pe %s:\n", name)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses the flags of a subcommand and applies the global settings
+func parseFlags(g *Globals, fs *flag.FlagSet, args []string) {
+	_ = fs.Parse(args)
+	chart.FontPath = g.Font
+}
+
+func usage(fs *flag.FlagSet) {
+	out := fs.Output()
+	fmt.Fprintf(out, "Usage: pe [global flags] <command> [flags]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-14s %s\n", c.Name, c.Description)
+	}
+	fmt.Fprintf(out, "\nGlobal flags:\n")
+	fs.PrintDefaults()
+	fmt.Fprintf(out, "\nRun \"pe <command> --help\" for the flags of a command.\n")
+}
+
+func main() {
+
+	// The global flags get a flag set of their own, the default one is cluttered by the flags of dependencies
+	g := newGlobals()
+	fs := flag.NewFlagSet("pe", flag.ExitOnError)
+	g.register(fs)
+	fs.Usage = func() { usage(fs) }
+	_ = fs.Parse(os.Args[1:])
+
+	if fs.NArg() == 0 {
+		usage(fs)
+		os.Exit(2)
+	}
+
+	name := fs.Arg(0)
+	for _, c := range commands {
+		if c.Name == name {
+			started := time.Now().UTC()
+			c.Run(g, fs.Args()[1:])
+			g.recordRun(name, fs.Args()[1:], started)
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command: %s\n\n", name)
+	usage(fs)
+	os.Exit(2)
+}
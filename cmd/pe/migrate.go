@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"pattern-evaluator/pkg/artifact"
+	"pattern-evaluator/pkg/bucket"
+	"pattern-evaluator/pkg/manifest"
+	"pattern-evaluator/pkg/pipeline"
+	"pattern-evaluator/pkg/trendscan"
+	"pattern-evaluator/pkg/triplebarrier"
+	"strings"
+)
+
+// migrateMetrics converts the metrics that process wrote before it wrote cubes into the cubes of the current grid,
+// the metrics are removed once their cube is written
+func migrateMetrics(g *Globals, m *manifest.Manifest, dryRun bool) {
+	files, err := filepath.Glob(filepath.Join(g.OpenRun().Dir, "metrics", "*.gob"))
+	if err != nil {
+		panic(err)
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	grid := g.LoadParams()
+	gob.Register(triplebarrier.BarrierMetrics{})
+	gob.Register(bucket.BucketMetrics{})
+	gob.Register(trendscan.TrendMetrics{})
+
+	converted, failed := 0, 0
+	for _, file := range files {
+		evaluator, algoName, ok := strings.Cut(strings.TrimSuffix(filepath.Base(file), ".gob"), "_")
+		if !ok {
+			fmt.Printf("  %s: not named after an evaluator and an algorithm\n", file)
+			failed++
+			continue
+		}
+		output := cubePath(g, evaluator, algoName)
+		if dryRun {
+			fmt.Printf("  %s: to %s\n", file, output)
+			converted++
+			continue
+		}
+		if _, err = artifact.Migrate(file, artifact.KindMetrics); err != nil {
+			fmt.Printf("failed: %v\n", err)
+			failed++
+			continue
+		}
+		var results pipeline.Results
+		if err = artifact.ReadGob(file, artifact.KindMetrics, &results); err != nil {
+			fmt.Printf("failed: %v\n", err)
+			failed++
+			continue
+		}
+		if err = artifact.WriteGob(output, artifact.KindCube, pipeline.Cube(algoName, results, grid)); err != nil {
+			panic(err)
+		}
+		if err = m.Rename(file, output); err != nil {
+			panic(err)
+		}
+		for _, path := range []string{file, file + artifact.ChecksumExt} {
+			if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+				panic(err)
+			}
+		}
+		converted++
+	}
+	verb := "converted"
+	if dryRun {
+		verb = "to convert"
+	}
+	fmt.Printf("[metrics] %d of %d files %s to cubes", converted, len(files), verb)
+	if failed > 0 {
+		fmt.Printf(", %d failed", failed)
+	}
+	fmt.Println()
+}
+
+func runMigrate(g *Globals, args []string) {
+
+	fs := newFlagSet(g, "migrate")
+	dryRun := fs.Bool("dry-run", false, "only list the files that would be upgraded")
+	parseFlags(g, fs, args)
+
+	m := g.Manifest()
+	stores := []struct {
+		dir  string
+		kind string
+	}{
+		{"events", artifact.KindEvents},
+		{"cubes", artifact.KindCube},
+		{"tables", artifact.KindTable},
+	}
+
+	for _, store := range stores {
+		files, err := filepath.Glob(filepath.Join(g.Dir(store.dir), "*.gob"))
+		if err != nil {
+			panic(err)
+		}
+
+		current := artifact.CurrentSchema(store.kind)
+		upgraded, failed := 0, 0
+		for _, file := range files {
+			h, err := artifact.Inspect(file, store.kind)
+			if err == nil && h.Kind == store.kind && h.Schema == current {
+				continue
+			}
+			if *dryRun {
+				if err != nil || h.Kind != store.kind || h.Schema > current {
+					fmt.Printf("  %s: cannot be upgraded\n", file)
+					failed++
+					continue
+				}
+				fmt.Printf("  %s: schema %d to %d\n", file, h.Schema, current)
+				upgraded++
+				continue
+			}
+			if _, err = artifact.Migrate(file, store.kind); err != nil {
+				fmt.Printf("failed: %v\n", err)
+				failed++
+				continue
+			}
+			if err = m.Rehash(file); err != nil {
+				panic(err)
+			}
+			upgraded++
+		}
+		verb := "upgraded"
+		if *dryRun {
+			verb = "to upgrade"
+		}
+		fmt.Printf("[%s] %d of %d files %s to schema %d", store.dir, upgraded, len(files), verb, current)
+		if failed > 0 {
+			fmt.Printf(", %d failed", failed)
+		}
+		fmt.Println()
+	}
+	migrateMetrics(g, m, *dryRun)
+
+	if !*dryRun {
+		if err := m.Save(); err != nil {
+			panic(err)
+		}
+	}
+}
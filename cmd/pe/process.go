@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/gob"
+	"fmt"
+	"pattern-evaluator/pkg/artifact"
+	"pattern-evaluator/pkg/bucket"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/manifest"
+	"pattern-evaluator/pkg/pipeline"
+	"pattern-evaluator/pkg/trendscan"
+	"pattern-evaluator/pkg/triplebarrier"
+	"time"
+)
+
+func ProcessForAlgorithm(g *Globals, algoName string, evaluator string, grid *config.EvalParams, universe *config.Universe, symbols []string) {
+
+	outputPath := cubePath(g, evaluator, algoName)
+
+	startTime := time.Now().UTC().UnixMilli()
+
+	events := make(pipeline.Events)
+	for _, symbol := range symbols {
+		scenarios := loadScenarios(g, algoName, symbol)
+		if scenarios == nil {
+			fmt.Printf("[%s, %s] no events harvested for %s\n", algoName, evaluator, symbol)
+			continue
+		}
+		events[symbol] = scenarios
+	}
+
+	opts := g.Options()
+	opts.Universe = universe
+	results, err := pipeline.Process(algoName, evaluator, events, grid, opts)
+	if err != nil {
+		panic(err)
+	}
+
+	gob.Register(triplebarrier.BarrierMetrics{})
+	gob.Register(bucket.BucketMetrics{})
+	gob.Register(trendscan.TrendMetrics{})
+	err = artifact.WriteGob(outputPath, artifact.KindCube, pipeline.Cube(algoName, results, grid))
+	if err != nil {
+		panic(err)
+	}
+
+	elapsed := time.Now().UTC().UnixMilli() - startTime
+	fmt.Printf("[%s, %s] Took %d milliseconds\n", algoName, evaluator, elapsed)
+}
+
+// processTargets are the cubes of every algorithm and evaluator, which depend on the harvested events of all symbols
+// and on everything that changes how they are evaluated
+func processTargets(g *Globals, universe *config.Universe, symbols []string, grid *config.EvalParams, algoFilter string, evaluatorFilter string) []Target {
+	settings := manifest.HashValue([]interface{}{config.GetBenchmarkSymbol(), config.GetEarningsWindow()})
+	candles, err := config.GetCandleVersion()
+	if err != nil {
+		panic(err)
+	}
+	targets := make([]Target, 0)
+	for _, algoName := range universe.Algorithms {
+		if !selected(algoFilter, algoName) {
+			continue
+		}
+		events := make([]string, 0, len(symbols))
+		for _, symbol := range symbols {
+			events = append(events, eventsPath(g, algoName, symbol))
+		}
+		inputs := manifest.Inputs{
+			"params":   manifest.HashValue(grid.ForAlgorithm(algoName).Combinations()),
+			"universe": manifest.HashValue(universe),
+			"events":   g.hashOutputs(events),
+			"candles":  candles,
+			"settings": settings,
+			"code":     manifest.CodeVersion(),
+		}
+		for _, ev := range evaluators() {
+			if !selected(evaluatorFilter, ev) {
+				continue
+			}
+			a, e := algoName, ev
+			targets = append(targets, Target{
+				Name:    "cube " + e + " " + a,
+				Outputs: []string{cubePath(g, e, a)},
+				Deps:    events,
+				Inputs:  inputs,
+				Build: func() error {
+					ProcessForAlgorithm(g, a, e, grid, universe, symbols)
+					return nil
+				},
+			})
+		}
+	}
+	return targets
+}
+
+func runProcess(g *Globals, args []string) {
+
+	fs := newFlagSet(g, "process")
+	evaluatorFilter := fs.String("evaluator", "", "only run this evaluator")
+	algoFilter := fs.String("algo", "", "only process this algorithm")
+	parseFlags(g, fs, args)
+
+	universe, symbols := g.LoadSymbols()
+	grid := g.LoadParams()
+
+	targets := processTargets(g, universe, symbols, grid, *algoFilter, *evaluatorFilter)
+	g.build("process", targets, len(targets))
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"pattern-evaluator/pkg/pipeline"
+	"strings"
+)
+
+// formatVsRandom leaves the difference to random events blank when there were none
+func formatVsRandom(c pipeline.Configuration) string {
+	if !c.HasRandom {
+		return ""
+	}
+	return fmt.Sprintf("%.2f", c.VsRandom)
+}
+
+// writeRankingCSV stores every ranked configuration, the rank allows restoring the order after sorting by another column
+func writeRankingCSV(configs []pipeline.Configuration, key string, alpha float64, outPath string) {
+	var o strings.Builder
+	o.WriteString(fmt.Sprintf("rank,algorithm,evaluator,table,row,column,size,%s,vs_random,p,q,significant\n", key))
+	for i, c := range configs {
+		o.WriteString(fmt.Sprintf("%d,%s,%s,%s,%s,%s,%d,%.2f,%s,%.4f,%.4f,%t\n", i+1, c.Algorithm, c.Evaluator, c.Table,
+			c.Row, c.Column, c.Size, c.Value, formatVsRandom(c), c.PValue, c.QValue, c.QValue < alpha))
+	}
+	writeText(outPath, o.String())
+}
+
+// writeRankingMarkdown stores the top configurations as a Markdown table
+func writeRankingMarkdown(configs []pipeline.Configuration, key string, alpha float64, top int, tested int, outPath string) {
+	var o strings.Builder
+	o.WriteString(fmt.Sprintf("# Top configurations by %s\n\n", key))
+	o.WriteString(fmt.Sprintf("%d configurations were tested on whether their win rate beats that of random events in "+
+		"the same cell, or a coin flip without them. q is the p-value adjusted with Benjamini-Hochberg, "+
+		"configurations with q below %g are marked significant.\n\n", tested, alpha))
+	o.WriteString(fmt.Sprintf("| rank | algorithm | evaluator | table | row | column | size | %s | vs random | p | q | significant |\n", key))
+	o.WriteString("|---:|---|---|---|---|---|---:|---:|---:|---:|---:|:---:|\n")
+	for i, c := range configs {
+		if i == top {
+			break
+		}
+		mark := ""
+		if c.QValue < alpha {
+			mark = "yes"
+		}
+		o.WriteString(fmt.Sprintf("| %d | %s | %s | %s | %s | %s | %d | %.2f | %s | %.4f | %.4f | %s |\n", i+1, c.Algorithm,
+			c.Evaluator, c.Table, c.Row, c.Column, c.Size, c.Value, formatVsRandom(c), c.PValue, c.QValue, mark))
+	}
+	writeText(outPath, o.String())
+}
+
+func runRank(g *Globals, args []string) {
+
+	fs := newFlagSet(g, "rank")
+	key := fs.String("key", "balanced", "metric to rank the configurations by")
+	minSize := fs.Int("min-size", 50, "fewest trades for a configuration to be ranked")
+	alpha := fs.Float64("alpha", 0.05, "false discovery rate below which a configuration is significant")
+	top := fs.Int("top", 50, "number of configurations in the Markdown report, the csv file has all of them")
+	significant := fs.Bool("significant", false, "only report significant configurations")
+	tableFilter := fs.String("table", "", "only rank this table, such as by-range")
+	evaluatorFilter := fs.String("evaluator", "", "only rank this evaluator")
+	algoFilter := fs.String("algo", "", "only rank this algorithm")
+	parseFlags(g, fs, args)
+
+	run, pivots := g.OpenRun(), g.LoadPivots()
+	configs := make([]pipeline.Configuration, 0)
+	tables := 0
+	for _, algoName := range g.LoadUniverse().Algorithms {
+		// Random events are the baseline every configuration is held against
+		if algoName == "random" || !selected(*algoFilter, algoName) {
+			continue
+		}
+		for _, ev := range evaluators() {
+			if !selected(*evaluatorFilter, ev) {
+				continue
+			}
+			for _, name := range pipeline.TableNames(pivots) {
+				if !selected(*tableFilter, name) {
+					continue
+				}
+				table := openTable(run, tableName(name, ev, algoName))
+				if table == nil {
+					continue
+				}
+				random := openTable(run, tableName(name, ev, "random"))
+				configs = append(configs, pipeline.Configurations(name, ev, algoName, table, random, *key, *minSize)...)
+				tables++
+			}
+		}
+	}
+
+	// Adjusting needs every test, so significance is decided before dropping anything
+	tested := len(configs)
+	pipeline.RankConfigurations(configs)
+	if *significant {
+		kept := make([]pipeline.Configuration, 0)
+		for _, c := range configs {
+			if c.QValue < *alpha {
+				kept = append(kept, c)
+			}
+		}
+		configs = kept
+	}
+
+	outputDir := g.Dir("ranking")
+	writeRankingCSV(configs, *key, *alpha, filepath.Join(outputDir, "configurations.csv"))
+	writeRankingMarkdown(configs, *key, *alpha, *top, tested, filepath.Join(outputDir, "configurations.md"))
+	fmt.Printf("ranked %d configurations of %d tables, written to %s\n", tested, tables, outputDir)
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"pattern-evaluator/pkg/artifact"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/manifest"
+	"pattern-evaluator/pkg/runs"
+	"strings"
+	"time"
+)
+
+// newRun is the run reference that starts a new run
+const newRun = "new"
+
+// OpenRun resolves the run of the --run flag, the first run and every "new" run are created on demand
+func (g *Globals) OpenRun() *runs.Run {
+	g.runOnce.Do(func() {
+		var err error
+		_, latestErr := runs.LatestID(g.Runs)
+		switch {
+		case g.Run == newRun:
+			g.run, err = createRun(g.Runs, runs.Latest)
+		case g.Run == runs.Latest && latestErr != nil:
+			g.run, err = createRun(g.Runs, "")
+		default:
+			g.run, err = runs.Open(g.Runs, g.Run)
+		}
+		if err != nil {
+			panic(err)
+		}
+	})
+	return g.run
+}
+
+// createRun starts a new run that shares the harvested events of the run it is created from, if any
+func createRun(root string, from string) (*runs.Run, error) {
+	// There is nothing to share before the first run
+	if _, err := runs.LatestID(root); err != nil && from == runs.Latest {
+		from = ""
+	}
+	var base *runs.Run
+	if from != "" {
+		b, err := runs.Open(root, from)
+		if err != nil {
+			return nil, err
+		}
+		base = b
+	}
+
+	r, err := runs.Create(root)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("created run %s\n", r.ID)
+	if base == nil {
+		return r, nil
+	}
+
+	linked, err := shareEvents(base, r)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("sharing %d harvested event files with run %s\n", linked, base.ID)
+	return r, nil
+}
+
+// shareEvents links the events of one run into another along with their manifest entries, so they are only
+// harvested again when their inputs change
+func shareEvents(from *runs.Run, to *runs.Run) (int, error) {
+	src := filepath.Join(from.Dir, "events")
+	dst := filepath.Join(to.Dir, "events")
+	entries, err := os.ReadDir(src)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err = os.MkdirAll(dst, 0755); err != nil {
+		return 0, err
+	}
+
+	linked := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if err = linkFile(filepath.Join(src, name), filepath.Join(dst, name)); err != nil {
+			return linked, err
+		}
+		if strings.HasSuffix(name, ".gob") {
+			linked++
+		}
+	}
+
+	fromManifest, err := manifest.Load(filepath.Join(from.Dir, "manifest.json"))
+	if err != nil {
+		return linked, err
+	}
+	toManifest := manifest.New(filepath.Join(to.Dir, "manifest.json"))
+	for key, entry := range fromManifest.Entries {
+		if strings.HasPrefix(key, "events/") {
+			toManifest.Entries[key] = entry
+		}
+	}
+	return linked, toManifest.Save()
+}
+
+// linkFile hard links a file, falling back to a copy where links are not supported. Outputs are always replaced
+// through a rename, so a linked file is never changed underneath the other run. A copy is written along with its
+// checksum sidecar, so sidecars are not copied by themselves
+func linkFile(src string, dst string) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	if strings.HasSuffix(src, artifact.ChecksumExt) {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return artifact.Write(dst, func(w io.Writer) error {
+		_, err := io.Copy(w, in)
+		return err
+	})
+}
+
+func readSnapshot(filename string) string {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// recordRun stores what a command ran with in the metadata of the run, if the command used a run at all
+func (g *Globals) recordRun(name string, args []string, started time.Time) {
+	if g.run == nil {
+		return
+	}
+	r := g.run
+	r.Revision = artifact.Producer()
+	r.Settings["benchmark"] = config.GetBenchmarkSymbol()
+	r.Settings["earnings-window"] = fmt.Sprintf("%d", config.GetEarningsWindow())
+	if candles, err := config.GetCandleVersion(); err == nil {
+		r.Settings["candles"] = candles
+	}
+	r.Settings["evaluators"] = strings.Join(evaluators(), " ")
+	r.Record(name, args, started, g.inputs)
+	if err := r.Save(); err != nil {
+		panic(err)
+	}
+}
+
+var stageOrder = []string{"harvest", "process", "distil", "tap"}
+
+func listRuns(g *Globals) {
+	all, err := runs.List(g.Runs)
+	if err != nil {
+		panic(err)
+	}
+	latest, _ := runs.LatestID(g.Runs)
+	for _, r := range all {
+		marker := " "
+		if r.ID == latest {
+			marker = "*"
+		}
+		stages := make([]string, 0)
+		for _, stage := range stageOrder {
+			if _, ok := r.Stages[stage]; ok {
+				stages = append(stages, stage)
+			}
+		}
+		created := time.Unix(r.Created, 0).Local().Format("2006-01-02 15:04")
+		fmt.Printf("%s %-20s %s  %-30s %s\n", marker, r.ID, created, strings.Join(stages, ","), strings.Join(r.Tags, ","))
+	}
+}
+
+func runRuns(g *Globals, args []string) {
+
+	fs := newFlagSet(g, "runs")
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage of pe runs:\n")
+		fmt.Fprintf(out, "  pe runs list                      list the runs, the latest one is marked with *\n")
+		fmt.Fprintf(out, "  pe runs new [run]                 start a run sharing the events of the latest or given run\n")
+		fmt.Fprintf(out, "  pe runs tag [--remove] <run> <tag>...\n")
+		fmt.Fprintf(out, "  pe runs delete [--tagged] <run>...\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	remove := fs.Bool("remove", false, "remove the tags instead of adding them")
+	tagged := fs.Bool("tagged", false, "also delete runs that have been tagged")
+	parseFlags(g, fs, args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	action := fs.Arg(0)
+	parseFlags(g, fs, fs.Args()[1:])
+	rest := fs.Args()
+
+	switch action {
+	case "list":
+		listRuns(g)
+
+	case "new":
+		from := runs.Latest
+		if len(rest) > 0 {
+			from = rest[0]
+		}
+		if _, err := createRun(g.Runs, from); err != nil {
+			panic(err)
+		}
+
+	case "tag":
+		if len(rest) < 2 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		r, err := runs.Open(g.Runs, rest[0])
+		if err != nil {
+			panic(err)
+		}
+		for _, tag := range rest[1:] {
+			if tag == runs.Latest || tag == newRun {
+				fmt.Printf("%s is reserved and cannot be used as a tag\n", tag)
+				os.Exit(1)
+			}
+			if *remove {
+				r.Untag(tag)
+			} else {
+				r.Tag(tag)
+			}
+		}
+		if err = r.Save(); err != nil {
+			panic(err)
+		}
+
+	case "delete":
+		if len(rest) == 0 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		for _, ref := range rest {
+			r, err := runs.Open(g.Runs, ref)
+			if err != nil {
+				panic(err)
+			}
+			if len(r.Tags) > 0 && !*tagged {
+				fmt.Printf("kept %s, it is tagged %s, use --tagged to delete it anyway\n", r.ID, strings.Join(r.Tags, ","))
+				continue
+			}
+			if err = runs.Delete(g.Runs, r.ID); err != nil {
+				panic(err)
+			}
+			fmt.Printf("deleted %s\n", r.ID)
+		}
+
+	default:
+		fmt.Printf("unknown action: %s\n\n", action)
+		fs.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/evaluate"
+	"pattern-evaluator/pkg/techniques"
+	"strings"
+	"sync"
+)
+
+type View struct {
+	Symbols int
+	Events  int
+	Metrics evaluate.Metrics
+}
+
+// EvaluateView combines the metrics of every harvested scenario of the symbols, dropping unlisted events if requested
+func EvaluateView(g *Globals, algoName string, ev evaluate.Evaluator, params evaluate.ParamSet, universe *config.Universe, symbols []string, pointInTime bool) View {
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, g.Workers)
+	viewLock := sync.Mutex{}
+	view := View{}
+
+	for _, symbol := range symbols {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(s string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			scenarios := loadScenarios(g, algoName, s)
+			if scenarios == nil {
+				return
+			}
+			if pointInTime {
+				var err error
+				if scenarios, err = universe.ListedScenarios(s, scenarios); err != nil {
+					panic(err)
+				}
+			}
+			for _, scenario := range scenarios {
+				events := scenario.Events
+				metrics := ev.Evaluate(&params, s, events)
+				viewLock.Lock()
+				view.Events += len(events)
+				if view.Metrics == nil {
+					view.Metrics = metrics
+				} else {
+					view.Metrics = view.Metrics.Combine(metrics)
+				}
+				viewLock.Unlock()
+			}
+			viewLock.Lock()
+			view.Symbols++
+			viewLock.Unlock()
+		}(symbol)
+	}
+	wg.Wait()
+
+	return view
+}
+
+func runSurvivorship(g *Globals, args []string) {
+
+	fs := newFlagSet(g, "survivorship")
+	threshold := fs.Float64("threshold", 0.05, "barrier threshold to evaluate")
+	timeout := fs.Int64("timeout", 14, "time limit to evaluate")
+	evaluator := fs.String("evaluator", "barriers", "evaluator to report on")
+	key := fs.String("key", "balanced", "metric to compare")
+	parseFlags(g, fs, args)
+
+	ev := techniques.GetHandler(*evaluator)
+	if ev == nil {
+		fmt.Printf("unknown evaluator: %s\n", *evaluator)
+		os.Exit(1)
+	}
+	params := evaluate.ParamSet{Threshold: *threshold, Timeout: *timeout}
+
+	universe := g.LoadUniverse()
+
+	// The report always compares against the point-in-time universe, regardless of the configured default
+	universe.PointInTime = true
+	symbols, err := universe.Symbols()
+	if err != nil {
+		panic(err)
+	}
+	survivors, err := universe.Survivors(symbols)
+	if err != nil {
+		panic(err)
+	}
+
+	var o strings.Builder
+	o.WriteString(fmt.Sprintf("algorithm,survivor_symbols,survivor_events,survivor_%s,pit_symbols,pit_events,pit_%s,shift\n", *key, *key))
+
+	fmt.Printf("%-20s %16s %10s %10s %10s\n", "algorithm", "survivor events", "pit events", *key, "shift")
+	for _, algoName := range universe.Algorithms {
+		biased := EvaluateView(g, algoName, ev, params, universe, survivors, false)
+		unbiased := EvaluateView(g, algoName, ev, params, universe, symbols, true)
+		if biased.Metrics == nil || unbiased.Metrics == nil {
+			fmt.Printf("%-20s no events harvested\n", algoName)
+			continue
+		}
+		before := biased.Metrics.Emit(*key)
+		after := unbiased.Metrics.Emit(*key)
+		fmt.Printf("%-20s %16d %10d %10.2f %+10.2f\n", algoName, biased.Events, unbiased.Events, after, after-before)
+		line := fmt.Sprintf("%s,%d,%d,%f,%d,%d,%f,%f\n", algoName, biased.Symbols, biased.Events, before, unbiased.Symbols, unbiased.Events, after, after-before)
+		o.WriteString(line)
+	}
+	writeText(filepath.Join(g.Dir(), "survivorship.csv"), o.String())
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"pattern-evaluator/pkg/artifact"
+	"pattern-evaluator/pkg/bucket"
+	"pattern-evaluator/pkg/chart"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/evaluate"
+	"pattern-evaluator/pkg/manifest"
+	"pattern-evaluator/pkg/pipeline"
+	"pattern-evaluator/pkg/trendscan"
+	"pattern-evaluator/pkg/triplebarrier"
+	"strconv"
+	"strings"
+)
+
+func decodeTable(filePath string) (*evaluate.MetricsTable, error) {
+	var output evaluate.MetricsTable
+	gob.Register(triplebarrier.BarrierMetrics{})
+	gob.Register(bucket.BucketMetrics{})
+	gob.Register(trendscan.TrendMetrics{})
+	err := artifact.ReadGob(filePath, artifact.KindTable, &output)
+	if err != nil {
+		return nil, err
+	}
+	return &output, nil
+}
+
+var tapImages = []string{"diff", "balanced", "worst", "size"}
+var tapKeys = []string{"balanced", "worst", "size", "wins"}
+var robustnessKeys = []string{"robustness", "mean", "stddev"}
+
+// TapTable renders a table as heatmaps and csv files, with a diff against the table of random events if there is one
+// and the robustness of its cells when the radius is positive
+func TapTable(g *Globals, pivot config.Pivot, tablePath string, randomPath string, radius int) error {
+
+	table, err := decodeTable(tablePath)
+	if err != nil {
+		return err
+	}
+	fileName := strings.TrimSuffix(path.Base(tablePath), path.Ext(tablePath))
+	fmt.Println(fileName)
+
+	tableRandom, err := decodeTable(randomPath)
+	if os.IsNotExist(err) {
+		tableRandom = nil
+	} else if err != nil {
+		return err
+	}
+
+	title := strings.ReplaceAll(fileName, "_", " ")
+	for kind, img := range pipeline.Tap(title, pivot, table, tableRandom, radius) {
+		chart.SavePNG(img, filepath.Join(g.Dir("png", kind), fileName+".png"))
+	}
+
+	for _, key := range tapKeys {
+		outPath := filepath.Join(g.Dir("csv", key), fileName+".csv")
+		evaluate.DumpMetrics(table.Values, key, outPath, table.Rows, table.Columns)
+	}
+	if radius > 0 {
+		robustness := pipeline.Robustness(pivot, table, radius)
+		for _, key := range robustnessKeys {
+			outPath := filepath.Join(g.Dir("csv", "robustness", key), fileName+".csv")
+			evaluate.DumpMetrics(robustness.Values, key, outPath, robustness.Rows, robustness.Columns)
+		}
+	}
+	return nil
+}
+
+// SymbolOptions select the symbols that get heatmaps of their own
+type SymbolOptions struct {
+	Count   int
+	Key     string
+	MinSize int
+}
+
+// symbolFile names the output of a symbol, symbols contain colons
+func symbolFile(symbol string) string {
+	return strings.ReplaceAll(symbol, ":", "_")
+}
+
+// TapSymbols renders the pivot of the symbols that do best and worst over the pivot as a heatmap each, along with
+// a csv file of their standings
+func TapSymbols(g *Globals, cubePath string, pivot config.Pivot, fileName string, opts SymbolOptions) error {
+
+	cube, err := decodeCube(cubePath)
+	if err != nil {
+		return err
+	}
+	standings, err := pipeline.RankSymbols(pivot, cube, opts.Key, opts.MinSize, defaultConfidence)
+	if err != nil {
+		return err
+	}
+	fmt.Println(fileName)
+
+	// Charts of symbols that dropped out of the top and bottom are removed
+	dir := filepath.Join(g.Dir("png", "symbols"), fileName)
+	if err = os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	places := make([]string, 0)
+	picks := make([]pipeline.Standing, 0)
+	for i := 0; i < opts.Count && i < len(standings); i++ {
+		places = append(places, fmt.Sprintf("top-%02d", i+1))
+		picks = append(picks, standings[i])
+	}
+	for i := 0; i < opts.Count && len(standings)-1-i >= opts.Count; i++ {
+		places = append(places, fmt.Sprintf("bottom-%02d", i+1))
+		picks = append(picks, standings[len(standings)-1-i])
+	}
+
+	var o strings.Builder
+	o.WriteString(fmt.Sprintf("place,symbol,size,%s,win_rate_low,win_rate_high\n", opts.Key))
+	for i, s := range picks {
+		table, err := pipeline.SymbolPivot(pivot, cube, s.Symbol)
+		if err != nil {
+			return err
+		}
+		title := strings.ReplaceAll(fileName, "_", " ") + " " + s.Symbol + " " + places[i]
+		chart.SavePNG(chart.Heatmap(title, table, opts.Key), filepath.Join(dir, places[i]+"_"+symbolFile(s.Symbol)+".png"))
+		o.WriteString(fmt.Sprintf("%s,%s,%d,%.2f,%.2f,%.2f\n", places[i], s.Symbol, s.Size, s.Value, s.Lower, s.Upper))
+	}
+	writeText(filepath.Join(g.Dir("png", "symbols"), fileName+".csv"), o.String())
+	return nil
+}
+
+// symbolTargets are the heatmaps of the best and worst symbols of every pivot, pivots by symbol are left out
+func symbolTargets(g *Globals, universe *config.Universe, pivots []config.Pivot, opts SymbolOptions) []Target {
+	targets := make([]Target, 0)
+	for _, algoName := range universe.Algorithms {
+		for _, ev := range evaluators() {
+			for _, pivot := range pivots {
+				if pivot.Uses(config.SymbolDimension) {
+					continue
+				}
+				cube, p := cubePath(g, ev, algoName), pivot
+				fileName := tableName(pivot.Name, ev, algoName)
+				targets = append(targets, Target{
+					Name:    "symbol charts " + fileName,
+					Outputs: []string{filepath.Join(g.Dir("png", "symbols"), fileName+".csv")},
+					Sources: []string{cube},
+					Inputs: manifest.Inputs{
+						"cube":    g.hashOutputs([]string{cube}),
+						"pivot":   pivot.String(),
+						"symbols": manifest.HashValue(opts),
+						"font":    g.Font,
+						"code":    manifest.CodeVersion(),
+					},
+					Build: func() error {
+						return TapSymbols(g, cube, p, fileName, opts)
+					},
+				})
+			}
+		}
+	}
+	return targets
+}
+
+// tapTargets are the heatmaps and csv files of every table, including their robustness when the radius is positive
+func tapTargets(g *Globals, universe *config.Universe, pivots []config.Pivot, radius int) []Target {
+	hasRandom := false
+	for _, algoName := range universe.Algorithms {
+		hasRandom = hasRandom || algoName == "random"
+	}
+
+	targets := make([]Target, 0)
+	for _, algoName := range universe.Algorithms {
+		for _, ev := range evaluators() {
+			for _, pivot := range pivots {
+				for _, name := range pipeline.TableNames([]config.Pivot{pivot}) {
+					table := tablePath(g, name, ev, algoName)
+					random := tablePath(g, name, ev, "random")
+					fileName := tableName(name, ev, algoName)
+					outputs := make([]string, 0)
+					for _, kind := range tapImages {
+						if kind == "diff" && !hasRandom {
+							continue
+						}
+						outputs = append(outputs, filepath.Join(g.Dir("png", kind), fileName+".png"))
+					}
+					for _, key := range tapKeys {
+						outputs = append(outputs, filepath.Join(g.Dir("csv", key), fileName+".csv"))
+					}
+					if radius > 0 {
+						outputs = append(outputs, filepath.Join(g.Dir("png", "robustness"), fileName+".png"))
+						for _, key := range robustnessKeys {
+							outputs = append(outputs, filepath.Join(g.Dir("csv", "robustness", key), fileName+".csv"))
+						}
+					}
+					p := pivot
+					targets = append(targets, Target{
+						Name:    "charts " + fileName,
+						Outputs: outputs,
+						Sources: []string{table},
+						Deps:    []string{random},
+						Inputs: manifest.Inputs{
+							"table":      g.hashOutputs([]string{table}),
+							"random":     g.hashOutputs([]string{random}),
+							"pivot":      pivot.String(),
+							"robustness": strconv.Itoa(radius),
+							"font":       g.Font,
+							"code":       manifest.CodeVersion(),
+						},
+						Build: func() error {
+							return TapTable(g, p, table, random, radius)
+						},
+					})
+				}
+			}
+		}
+	}
+	return targets
+}
+
+func runTap(g *Globals, args []string) {
+
+	fs := newFlagSet(g, "tap")
+	symbols := SymbolOptions{}
+	fs.IntVar(&symbols.Count, "symbols", 0, "also chart every pivot for this many of the best and of the worst symbols")
+	fs.StringVar(&symbols.Key, "key", "balanced", "metric to pick the best and worst symbols by")
+	fs.IntVar(&symbols.MinSize, "min-size", 20, "fewest trades for a symbol to be picked")
+	radius := fs.Int("robustness", 0, "also chart the robustness of every cell over the neighbours within this many rows and columns")
+	parseFlags(g, fs, args)
+
+	universe, pivots := g.LoadUniverse(), g.LoadPivots()
+	g.build("tap", tapTargets(g, universe, pivots, *radius), 1)
+	if symbols.Count > 0 {
+		g.build("tap", symbolTargets(g, universe, pivots, symbols), 1)
+	}
+}
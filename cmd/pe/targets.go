@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"pattern-evaluator/pkg/artifact"
+	"pattern-evaluator/pkg/manifest"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Target is a group of outputs that are built together, it is rebuilt when its inputs change or when one of its
+// upstream outputs is rebuilt
+type Target struct {
+	Name    string
+	Outputs []string
+	// Sources are upstream outputs that must exist for the target to be built
+	Sources []string
+	// Deps are upstream outputs that are used when they exist
+	Deps   []string
+	Inputs manifest.Inputs
+	// Build returns an error when the target could not be built, its outputs are then left unrecorded
+	Build func() error
+}
+
+func (g *Globals) Manifest() *manifest.Manifest {
+	g.manifestOnce.Do(func() {
+		path := filepath.Join(g.Dir(), "manifest.json")
+		m, err := manifest.Load(path)
+		if artifact.IsCorrupt(err) {
+			fmt.Printf("%v, every output will be rebuilt\n", err)
+			m, err = manifest.New(path), nil
+		}
+		if err != nil {
+			panic(err)
+		}
+		g.manifest = m
+	})
+	return g.manifest
+}
+
+// hashOutputs combines the content hashes of upstream outputs into a single input
+func (g *Globals) hashOutputs(paths []string) string {
+	hashes := make(map[string]string)
+	for _, p := range paths {
+		hashes[filepath.Base(p)] = g.Manifest().Hash(p)
+	}
+	return manifest.HashValue(hashes)
+}
+
+// stale lists why a target has to be rebuilt, taking the outputs that are planned to be rebuilt into account
+func (g *Globals) stale(t Target, planned map[string]bool) []string {
+	reasons := make(map[string]bool)
+	if g.Force {
+		reasons["forced"] = true
+	}
+	for _, output := range t.Outputs {
+		for _, r := range g.Manifest().Changed(output, t.Inputs) {
+			reasons[r] = true
+		}
+	}
+	for _, dep := range append(append([]string{}, t.Sources...), t.Deps...) {
+		if planned[dep] {
+			reasons["upstream "+filepath.Base(dep)] = true
+		}
+	}
+	result := make([]string, 0, len(reasons))
+	for r := range reasons {
+		result = append(result, r)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// buildable reports whether the sources of a target exist or will be built before it
+func buildable(t Target, planned map[string]bool) bool {
+	for _, source := range t.Sources {
+		if _, err := os.Stat(source); err != nil && !planned[source] {
+			return false
+		}
+	}
+	return true
+}
+
+// build rebuilds the stale targets of a stage, running up to parallel builds at once, and records them in the manifest
+func (g *Globals) build(stage string, targets []Target, parallel int) {
+
+	m := g.Manifest()
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, parallel)
+	countLock := sync.Mutex{}
+	built := 0
+
+	for _, target := range targets {
+		if len(g.stale(target, nil)) == 0 {
+			continue
+		}
+		if !buildable(target, nil) {
+			fmt.Printf("skipped: %s has nothing to build from\n", target.Name)
+			continue
+		}
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(t Target) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			if err := t.Build(); err != nil {
+				fmt.Printf("failed: %s: %v\n", t.Name, err)
+				return
+			}
+			for _, output := range t.Outputs {
+				// Outputs the build had nothing to write to are recorded as well, so that they are not rebuilt every time
+				if _, err := os.Stat(output); err != nil {
+					m.RecordEmpty(output, t.Inputs)
+					continue
+				}
+				if err := m.Record(output, t.Inputs); err != nil {
+					panic(err)
+				}
+			}
+			countLock.Lock()
+			built++
+			if built%50 == 0 {
+				if err := m.Save(); err != nil {
+					panic(err)
+				}
+			}
+			countLock.Unlock()
+		}(target)
+	}
+	wg.Wait()
+
+	if err := m.Save(); err != nil {
+		panic(err)
+	}
+	fmt.Printf("[%s] rebuilt %d of %d targets\n", stage, built, len(targets))
+}
+
+func runPlan(g *Globals, args []string) {
+
+	fs := newFlagSet(g, "plan")
+	verbose := fs.Bool("verbose", false, "list every stale target instead of a summary per stage")
+	parseFlags(g, fs, args)
+
+	universe, symbols := g.LoadSymbols()
+	grid := g.LoadParams()
+	pivots := g.LoadPivots()
+
+	stages := []struct {
+		name    string
+		targets func() []Target
+	}{
+		{"harvest", func() []Target { return harvestTargets(g, universe, symbols, grid, "") }},
+		{"process", func() []Target { return processTargets(g, universe, symbols, grid, "", "") }},
+		{"distil", func() []Target { return distilTargets(g, universe, pivots) }},
+		{"tap", func() []Target { return tapTargets(g, universe, pivots, 0) }},
+	}
+
+	planned := make(map[string]bool)
+	for _, stage := range stages {
+		targets := stage.targets()
+		stale := 0
+		byReason := make(map[string]int)
+		for _, t := range targets {
+			if !buildable(t, planned) {
+				continue
+			}
+			reasons := g.stale(t, planned)
+			if len(reasons) == 0 {
+				continue
+			}
+			stale++
+			for _, output := range t.Outputs {
+				planned[output] = true
+			}
+			for _, r := range reasons {
+				if strings.HasPrefix(r, "upstream ") {
+					r = "upstream"
+				}
+				byReason[r]++
+			}
+			if *verbose {
+				fmt.Printf("  %s: %s\n", t.Name, strings.Join(reasons, ", "))
+			}
+		}
+		summary := make([]string, 0)
+		for r, n := range byReason {
+			summary = append(summary, fmt.Sprintf("%s %d", r, n))
+		}
+		sort.Strings(summary)
+		fmt.Printf("%-8s %d of %d targets to rebuild", stage.name, stale, len(targets))
+		if len(summary) > 0 {
+			fmt.Printf(" (%s)", strings.Join(summary, ", "))
+		}
+		fmt.Println()
+	}
+}
@@ -1,40 +1,17 @@
 package main
 
 import (
-	"encoding/gob"
 	"fmt"
-	"github.com/godoji/algocore/pkg/algo"
 	"github.com/northberg/candlestick"
 	"log"
 	"math"
-	"os"
-	"path"
+	"pattern-evaluator/pkg/benchmark"
 	"pattern-evaluator/pkg/bucket"
-	"pattern-evaluator/pkg/config"
 	"pattern-evaluator/pkg/triplebarrier"
 	"pattern-evaluator/pkg/validator"
-	"strings"
 	"time"
 )
 
-func loadScenarios(algoName string, symbol string) []*algo.ScenarioSet {
-	fileName := algoName + "_" + strings.ReplaceAll(symbol, ":", "_") + ".gob"
-	outputPath := path.Join(".", "output", "events", fileName)
-
-	f, err := os.Open(outputPath)
-	if err != nil {
-		panic(err)
-	}
-	defer f.Close()
-
-	results := make([]*algo.ScenarioSet, 0)
-	err = gob.NewDecoder(f).Decode(&results)
-	if err != nil {
-		panic(err)
-	}
-	return results
-}
-
 func CalculateSD(values []float64, mul float64) (float64, float64) {
 	// Step 1: Calculate the mean
 	sum := 0.0
@@ -60,16 +37,16 @@ func CalculateSD(values []float64, mul float64) (float64, float64) {
 	return mean, sd
 }
 
-func validateForLimit(symbols []string) {
+func validateForLimit(g *Globals, symbols []string) {
 	fmt.Print("Size delta between time limits: ")
 	for _, symbol := range symbols {
-		scenarios := loadScenarios("random", symbol)
+		scenarios := loadScenarios(g, "random", symbol)
 		r1Sum := 0
 		r2Sum := 0
 		for _, scenario := range scenarios {
 			events := scenario.Events
-			r1 := triplebarrier.Evaluate(symbol, candlestick.Interval1d, events, 0.05, 3)
-			r2 := triplebarrier.Evaluate(symbol, candlestick.Interval1d, events, 0.05, 7)
+			r1 := triplebarrier.Evaluate(symbol, candlestick.Interval1d, events, 0.05, 3, benchmark.Raw)
+			r2 := triplebarrier.Evaluate(symbol, candlestick.Interval1d, events, 0.05, 7, benchmark.Raw)
 			r1Sum += r1.Size() + r1.Timeouts()
 			r2Sum += r2.Size() + r2.Timeouts()
 		}
@@ -80,7 +57,7 @@ func validateForLimit(symbols []string) {
 	fmt.Println("ok")
 }
 
-func validateForSymbol(symbols []string) {
+func validateForSymbol(g *Globals, symbols []string) {
 
 	startTime := time.Now().UTC().UnixMilli()
 
@@ -91,11 +68,11 @@ func validateForSymbol(symbols []string) {
 	pointAverage := 0.0
 	totalResults := 0
 	for _, symbol := range symbols {
-		scenarios := loadScenarios("random", symbol)
+		scenarios := loadScenarios(g, "random", symbol)
 		for _, scenario := range scenarios {
 			events := scenario.Events
-			buckets := bucket.Evaluate(symbol, candlestick.Interval1d, events, 0.07, 500)
-			barriers := triplebarrier.Evaluate(symbol, candlestick.Interval1d, events, 0.07, 500)
+			buckets := bucket.Evaluate(symbol, candlestick.Interval1d, events, 0.07, 500, benchmark.Raw)
+			barriers := triplebarrier.Evaluate(symbol, candlestick.Interval1d, events, 0.07, 500, benchmark.Raw)
 			returnAtPoint := validator.Evaluate(symbol, candlestick.Interval1d, events)
 
 			if math.IsNaN(barriers.SumReturn) {
@@ -152,12 +129,12 @@ func printConclusion(metric float64) {
 	}
 }
 
-func main() {
+func runValidate(g *Globals, args []string) {
+	fs := newFlagSet(g, "validate")
+	parseFlags(g, fs, args)
+
 	fmt.Println("Evaluating performance of random trades")
-	symbols, err := config.GetSymbolList()
-	if err != nil {
-		panic(err)
-	}
-	validateForSymbol(symbols)
-	validateForLimit(symbols)
+	_, symbols := g.LoadSymbols()
+	validateForSymbol(g, symbols)
+	validateForLimit(g, symbols)
 }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"pattern-evaluator/pkg/evaluate"
+	"pattern-evaluator/pkg/pipeline"
+	"strings"
+)
+
+// formatMetrics writes the size and the emitted value of the key, leaving the value blank without metrics
+func formatMetrics(m evaluate.Metrics, key string) string {
+	if m == nil {
+		return "0,"
+	}
+	return fmt.Sprintf("%d,%.2f", m.Size(), m.Emit(key))
+}
+
+// writeWalkForward stores every window of a walk-forward evaluation as a csv file, followed by the stitched totals
+func writeWalkForward(result *pipeline.WalkForwardResult, key string, outPath string) {
+	var o strings.Builder
+	o.WriteString(fmt.Sprintf("train_from,train_to,test,configuration,purged,is_size,is_%s,oos_size,oos_%s\n", key, key))
+	for _, w := range result.Windows {
+		o.WriteString(fmt.Sprintf("%d,%d,%d,%s,%d,%s,%s\n", w.TrainFrom, w.TrainTo, w.Test, w.Configuration, w.Purged,
+			formatMetrics(w.InSample, key), formatMetrics(w.OutOfSample, key)))
+	}
+	o.WriteString(fmt.Sprintf(",,,total,,%s,%s\n", formatMetrics(result.InSample, key), formatMetrics(result.OutOfSample, key)))
+	writeText(outPath, o.String())
+}
+
+func runWalkForward(g *Globals, args []string) {
+
+	fs := newFlagSet(g, "walkforward")
+	key := fs.String("key", "balanced", "metric to pick the best configuration by")
+	train := fs.Int("train", 3, "number of years to pick the best configuration over, the year after is tested")
+	minSize := fs.Int("min-size", 50, "fewest trades in the training years for a configuration to be picked")
+	threshold := fs.Float64("threshold", 0, "only evaluate this barrier threshold, 0 evaluates every threshold")
+	timeout := fs.Int64("timeout", 0, "only evaluate this time limit, 0 evaluates every time limit")
+	evaluatorFilter := fs.String("evaluator", "", "only evaluate this evaluator")
+	algoFilter := fs.String("algo", "", "only evaluate this algorithm")
+	parseFlags(g, fs, args)
+
+	universe, symbols := g.LoadSymbols()
+	grid := g.LoadParams()
+	if *threshold != 0 {
+		grid.Thresholds = []float64{*threshold}
+	}
+	if *timeout != 0 {
+		grid.TimeLimits = []int64{*timeout}
+	}
+
+	outputDir := g.Dir("walkforward")
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("evaluator,algorithm,windows,is_size,is_%s,oos_size,oos_%s\n", *key, *key))
+	for _, algoName := range universe.Algorithms {
+		if !selected(*algoFilter, algoName) {
+			continue
+		}
+		events := make(pipeline.Events)
+		for _, symbol := range symbols {
+			if scenarios := loadScenarios(g, algoName, symbol); scenarios != nil {
+				events[symbol] = scenarios
+			}
+		}
+		for _, ev := range evaluators() {
+			if !selected(*evaluatorFilter, ev) {
+				continue
+			}
+			opts := g.Options()
+			opts.Universe = universe
+			result, err := pipeline.WalkForward(algoName, ev, events, grid, *key, *train, *minSize, opts)
+			if err != nil {
+				panic(err)
+			}
+			name := ev + "_" + algoName
+			if len(result.Windows) == 0 {
+				fmt.Printf("skipped: %s spans too few years to train on %d\n", name, *train)
+				continue
+			}
+			writeWalkForward(result, *key, filepath.Join(outputDir, name+".csv"))
+			summary.WriteString(fmt.Sprintf("%s,%s,%d,%s,%s\n", ev, algoName, len(result.Windows),
+				formatMetrics(result.InSample, *key), formatMetrics(result.OutOfSample, *key)))
+			fmt.Printf("%s: in-sample %s, out-of-sample %s over %d windows\n", name,
+				formatMetrics(result.InSample, *key), formatMetrics(result.OutOfSample, *key), len(result.Windows))
+		}
+	}
+	writeText(filepath.Join(outputDir, "summary.csv"), summary.String())
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"pattern-evaluator/pkg/artifact"
+	"pattern-evaluator/pkg/bucket"
+	"pattern-evaluator/pkg/calendar"
+	"pattern-evaluator/pkg/chart"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/evaluate"
+	"pattern-evaluator/pkg/trendscan"
+	"pattern-evaluator/pkg/triplebarrier"
+	"sort"
+	"strings"
+)
+
+var emitKeys = []string{"balanced", "worst", "size", "wins"}
+
+// percentKeys are charted as win rates between 0 and 100 around a coin flip, other keys are scaled to their values
+var percentKeys = map[string]bool{"balanced": true, "worst": true}
+
+// scale returns the bounds and the reference line of a chart of the key over the values
+func scale(key string, values []float64) (float64, float64, float64) {
+	if percentKeys[key] {
+		return 0, 100, 50
+	}
+	min, max := chart.Bounds(values)
+	return min, max, 0
+}
+
+type Filter struct {
+	MinThreshold float64
+	MaxThreshold float64
+	Timeout      int64
+	Evaluator    string
+	Algorithm    string
+}
+
+// AggregateYearOverYear totals the cube within the filter, it returns the error of a cube that cannot be read and nil
+// metrics when nothing is within the filter
+func AggregateYearOverYear(inputPath string, filter Filter) (evaluate.Metrics, error) {
+
+	cube, err := decodeCube(inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cube, err = cube.Filter(config.ThresholdDimension, func(_ string, threshold float64) bool {
+		return threshold >= filter.MinThreshold && threshold <= filter.MaxThreshold
+	})
+	if err != nil {
+		panic(err)
+	}
+	if filter.Timeout != 0 {
+		// A timeout that was not evaluated leaves nothing within the filter
+		if cube, err = cube.Slice(config.TimeoutDimension, fmt.Sprintf("%d", filter.Timeout)); err != nil {
+			return nil, nil
+		}
+	}
+	return cube.Total(), nil
+}
+
+func sortedPeriods(periods map[int]evaluate.Metrics) []int {
+	keys := make([]int, 0)
+	for key := range periods {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// writeBreakdown stores the performance of every period of the dimension as a table and a bar chart
+func writeBreakdown(pm evaluate.PeriodMetrics, dimension string, key string, outputDir string, name string) {
+
+	periods := pm.ByPeriod(dimension)
+
+	var o strings.Builder
+	o.WriteString(dimension + ",label,performance," + strings.Join(emitKeys, ",") + "\n")
+
+	labels := make([]string, 0)
+	values := make([]float64, 0)
+	for _, p := range sortedPeriods(periods) {
+		period := periods[p]
+		label := calendar.Label(dimension, p)
+		line := fmt.Sprintf("%d,%s,%f", p, label, period.Value())
+		for _, k := range emitKeys {
+			line += fmt.Sprintf(",%f", period.Emit(k))
+		}
+		o.WriteString(line + "\n")
+		labels = append(labels, label)
+		values = append(values, period.Emit(key))
+	}
+
+	writeText(filepath.Join(outputDir, dimension+"_"+name+".csv"), o.String())
+
+	title := dimension + " " + strings.ReplaceAll(name, "_", " ")
+	min, max, reference := scale(key, values)
+	img := chart.BarChart(title, labels, values, min, max, reference)
+	chart.SavePNG(img, filepath.Join(outputDir, "png", dimension+"_"+name+".png"))
+}
+
+// writeComparison draws the yearly performance of an algorithm next to that of random events of the same evaluator
+func writeComparison(pm evaluate.PeriodMetrics, random evaluate.PeriodMetrics, key string, outputDir string, name string) {
+
+	years := pm.ByPeriod(calendar.Year)
+	randomYears := random.ByPeriod(calendar.Year)
+
+	union := make(map[int]evaluate.Metrics)
+	for y, m := range years {
+		union[y] = m
+	}
+	for y, m := range randomYears {
+		union[y] = m
+	}
+
+	labels := make([]string, 0)
+	series := []chart.Series{{Name: strings.Split(name, "_")[1]}, {Name: "random"}}
+	for _, y := range sortedPeriods(union) {
+		labels = append(labels, calendar.Label(calendar.Year, y))
+		for i, periods := range []map[int]evaluate.Metrics{years, randomYears} {
+			v := math.NaN()
+			if m, ok := periods[y]; ok && m.Size() > 0 {
+				v = m.Emit(key)
+			}
+			series[i].Values = append(series[i].Values, v)
+		}
+	}
+
+	title := "year " + strings.ReplaceAll(name, "_", " ") + " vs random"
+	min, max, reference := scale(key, append(append([]float64{}, series[0].Values...), series[1].Values...))
+	img := chart.LineChart(title, labels, series, min, max, reference)
+	chart.SavePNG(img, filepath.Join(outputDir, "png", "compare_"+name+".png"))
+}
+
+func runYearOverYear(g *Globals, args []string) {
+
+	fs := newFlagSet(g, "yoy")
+	filter := Filter{}
+	fs.Float64Var(&filter.MinThreshold, "min-threshold", 0.02, "smallest barrier threshold to include")
+	fs.Float64Var(&filter.MaxThreshold, "max-threshold", 0.05, "largest barrier threshold to include")
+	fs.Int64Var(&filter.Timeout, "timeout", 14, "time limit to include, 0 includes all")
+	fs.StringVar(&filter.Evaluator, "evaluator", "", "only include this evaluator")
+	fs.StringVar(&filter.Algorithm, "algo", "", "only include this algorithm")
+	key := fs.String("key", "balanced", "metric to chart")
+	parseFlags(g, fs, args)
+
+	gob.Register(triplebarrier.BarrierMetrics{})
+	gob.Register(bucket.BucketMetrics{})
+	gob.Register(trendscan.TrendMetrics{})
+
+	outputDir := g.Dir("yoy")
+	g.Dir("yoy", "png")
+
+	files, err := filepath.Glob(filepath.Join(g.Dir("cubes"), "*.gob"))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// Cubes are named after the evaluator and the algorithm
+	results := make(map[string]evaluate.PeriodMetrics)
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		xs := strings.Split(name, "_")
+		if len(xs) < 2 {
+			fmt.Printf("skipped: %s is not named after an evaluator and an algorithm\n", file)
+			continue
+		}
+		if filter.Evaluator != "" && xs[0] != filter.Evaluator {
+			continue
+		}
+		if filter.Algorithm != "" && xs[1] != filter.Algorithm && xs[1] != "random" {
+			continue
+		}
+		fmt.Println(file)
+		m, err := AggregateYearOverYear(file, filter)
+		if artifact.IsCorrupt(err) || artifact.IsVersion(err) {
+			fmt.Printf("skipped: %v\n", err)
+			continue
+		}
+		if err != nil {
+			panic(err)
+		}
+		if m == nil {
+			fmt.Printf("skipped: %s has no results within the filter\n", name)
+			continue
+		}
+		pm, ok := m.(evaluate.PeriodMetrics)
+		if !ok {
+			fmt.Printf("skipped: %s cannot be broken down by period\n", name)
+			continue
+		}
+		results[name] = pm
+	}
+
+	for name, pm := range results {
+		for _, dimension := range calendar.Dimensions() {
+			writeBreakdown(pm, dimension, *key, outputDir, name)
+		}
+		xs := strings.Split(name, "_")
+		if xs[1] == "random" {
+			continue
+		}
+		if random, ok := results[xs[0]+"_random"]; ok {
+			writeComparison(pm, random, *key, outputDir, name)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package artifact
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ChecksumExt is appended to the path of an artifact to name its checksum sidecar, which is in the format of sha256sum
+const ChecksumExt = ".sha256"
+
+// CorruptError reports an artifact that was written partially or changed after it was written
+type CorruptError struct {
+	Path   string
+	Reason string
+}
+
+func (e *CorruptError) Error() string {
+	return fmt.Sprintf("%s is corrupt: %s, build it again to replace it", e.Path, e.Reason)
+}
+
+func IsCorrupt(err error) bool {
+	var corrupt *CorruptError
+	return errors.As(err, &corrupt)
+}
+
+// Write replaces the file at the path with whatever write produces, readers either see the old or the new file in full.
+// The content goes to a temporary file that is synced and renamed into place once its checksum is stored
+func Write(path string, write func(w io.Writer) error) error {
+	h := sha256.New()
+	tmp, err := writeTemp(path, func(w io.Writer) error {
+		return write(io.MultiWriter(w, h))
+	})
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	sidecar, err := writeTemp(path+ChecksumExt, func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "%s  %s\n", sum, filepath.Base(path))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer os.Remove(sidecar)
+
+	// Either rename may be the last one to happen before a crash, a stale sidecar is then reported as a mismatch
+	if err = os.Rename(sidecar, path+ChecksumExt); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+func writeTemp(path string, write func(w io.Writer) error) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	buf := bufio.NewWriter(f)
+	err = write(buf)
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err == nil {
+		err = f.Chmod(0644)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	// Not every platform can sync a directory, the rename has happened either way
+	_ = d.Sync()
+	return nil
+}
+
+// Checksum returns the checksum stored next to the artifact, without verifying the artifact itself
+func Checksum(path string) (string, error) {
+	data, err := os.ReadFile(path + ChecksumExt)
+	if os.IsNotExist(err) {
+		return "", &CorruptError{Path: path, Reason: "it has no checksum"}
+	}
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", &CorruptError{Path: path, Reason: "its checksum is empty"}
+	}
+	return fields[0], nil
+}
+
+// Read returns the content of the artifact after verifying it against its checksum
+func Read(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	expected, err := Checksum(path)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return nil, &CorruptError{Path: path, Reason: fmt.Sprintf("its checksum is %.12s instead of %.12s", actual, expected)}
+	}
+	return data, nil
+}
@@ -0,0 +1,252 @@
+package artifact
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Kinds of gob artifacts, every kind has its own schema version
+const (
+	KindEvents = "events"
+	KindCube   = "cube"
+	KindTable  = "table"
+	// KindMetrics are the results by symbol that process wrote before it wrote cubes, they are only read to be migrated
+	KindMetrics = "metrics"
+)
+
+// headerMagic starts the first line of every versioned artifact, followed by the kind, schema and producer
+const headerMagic = "pe-artifact"
+
+// Migration upgrades the payload of an artifact from one schema version to the next
+type Migration func(payload []byte) ([]byte, error)
+
+// migrations holds the upgrade from every schema version of a kind to the next, so the length is the current schema.
+// Schema 0 are the files that were written before artifacts had a header, their payload is unchanged. Cubes were
+// never written without a header
+var migrations = map[string][]Migration{
+	KindEvents:  {unchanged},
+	KindCube:    {headed},
+	KindTable:   {unchanged},
+	KindMetrics: {unchanged},
+}
+
+func unchanged(payload []byte) ([]byte, error) {
+	return payload, nil
+}
+
+func headed(payload []byte) ([]byte, error) {
+	return nil, errors.New("this kind has always been written with a header, a file without one is not of this kind")
+}
+
+type Header struct {
+	Kind     string
+	Schema   int
+	Producer string
+}
+
+// VersionError reports an artifact whose schema this build cannot read
+type VersionError struct {
+	Path    string
+	Header  Header
+	Current int
+}
+
+func (e *VersionError) Error() string {
+	if e.Header.Schema > e.Current {
+		return fmt.Sprintf("%s holds %s in schema %d written by %s, this build only reads schema %d, update pe to read it",
+			e.Path, e.Header.Kind, e.Header.Schema, e.Header.Producer, e.Current)
+	}
+	if e.Header.Schema == 0 {
+		return fmt.Sprintf("%s holds %s without a schema version, this build reads schema %d, run pe migrate to upgrade it",
+			e.Path, e.Header.Kind, e.Current)
+	}
+	return fmt.Sprintf("%s holds %s in schema %d written by %s, this build reads schema %d, run pe migrate to upgrade it",
+		e.Path, e.Header.Kind, e.Header.Schema, e.Header.Producer, e.Current)
+}
+
+func IsVersion(err error) bool {
+	var version *VersionError
+	return errors.As(err, &version)
+}
+
+// CurrentSchema is the schema version of a kind written by this build
+func CurrentSchema(kind string) int {
+	steps, ok := migrations[kind]
+	if !ok {
+		panic(fmt.Sprintf("unknown artifact kind: %s", kind))
+	}
+	return len(steps)
+}
+
+var producer string
+var producerOnce sync.Once
+
+// Producer identifies the build that writes artifacts, by its vcs revision when it is known
+func Producer() string {
+	producerOnce.Do(readProducer)
+	return producer
+}
+
+func readProducer() {
+	producer = "devel"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		revision, modified := "", false
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				revision = s.Value
+			case "vcs.modified":
+				modified = s.Value == "true"
+			}
+		}
+		if len(revision) > 12 {
+			revision = revision[:12]
+		}
+		if revision != "" {
+			producer = revision
+			if modified {
+				producer += "-dirty"
+			}
+		}
+	}
+}
+
+func writeHeader(w io.Writer, h Header) error {
+	_, err := fmt.Fprintf(w, "%s %s %d %s\n", headerMagic, h.Kind, h.Schema, h.Producer)
+	return err
+}
+
+// splitHeader separates the header line from the payload, files without a header are of schema 0
+func splitHeader(path string, kind string, data []byte) (Header, []byte, error) {
+	if !bytes.HasPrefix(data, []byte(headerMagic+" ")) {
+		return Header{Kind: kind, Schema: 0, Producer: "unknown"}, data, nil
+	}
+	end := bytes.IndexByte(data, '\n')
+	if end < 0 {
+		return Header{}, nil, &CorruptError{Path: path, Reason: "its header is not terminated"}
+	}
+	fields := strings.Fields(string(data[:end]))
+	if len(fields) != 4 {
+		return Header{}, nil, &CorruptError{Path: path, Reason: fmt.Sprintf("its header %q is malformed", string(data[:end]))}
+	}
+	schema, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return Header{}, nil, &CorruptError{Path: path, Reason: fmt.Sprintf("its schema %q is not a number", fields[2])}
+	}
+	return Header{Kind: fields[1], Schema: schema, Producer: fields[3]}, data[end+1:], nil
+}
+
+func checkHeader(path string, kind string, h Header) error {
+	if h.Kind != kind {
+		return &CorruptError{Path: path, Reason: fmt.Sprintf("it holds %s instead of %s", h.Kind, kind)}
+	}
+	if current := CurrentSchema(kind); h.Schema != current {
+		return &VersionError{Path: path, Header: h, Current: current}
+	}
+	return nil
+}
+
+// WriteGob atomically writes a value as an artifact of the kind, headed by the current schema of the kind
+func WriteGob(path string, kind string, v interface{}) error {
+	h := Header{Kind: kind, Schema: CurrentSchema(kind), Producer: Producer()}
+	return Write(path, func(w io.Writer) error {
+		if err := writeHeader(w, h); err != nil {
+			return err
+		}
+		return gob.NewEncoder(w).Encode(v)
+	})
+}
+
+// ReadGob decodes a verified artifact of the kind into v, a missing artifact is reported as such, an artifact of
+// another schema as a VersionError and anything else as corrupt
+func ReadGob(path string, kind string, v interface{}) error {
+	data, err := Read(path)
+	if IsCorrupt(err) {
+		// Files from before checksums were written also lack a header, which is the more helpful thing to report
+		if h, inspectErr := Inspect(path, kind); inspectErr == nil && h.Schema == 0 {
+			return &VersionError{Path: path, Header: h, Current: CurrentSchema(kind)}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	h, payload, err := splitHeader(path, kind, data)
+	if err != nil {
+		return err
+	}
+	if err = checkHeader(path, kind, h); err != nil {
+		return err
+	}
+	if err = gob.NewDecoder(bytes.NewReader(payload)).Decode(v); err != nil {
+		return &CorruptError{Path: path, Reason: err.Error()}
+	}
+	return nil
+}
+
+// Inspect returns the header of an artifact without reading or verifying the rest of it
+func Inspect(path string, kind string) (Header, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Header{}, err
+	}
+	defer f.Close()
+	buf := make([]byte, 256)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return Header{}, err
+	}
+	h, _, err := splitHeader(path, kind, buf[:n])
+	return h, err
+}
+
+// Migrate upgrades an artifact of the kind to the current schema and returns the header it had before.
+// Artifacts from before checksums were written are accepted without one, as long as their payload can be upgraded
+func Migrate(path string, kind string) (Header, error) {
+	data, err := Read(path)
+	if IsCorrupt(err) {
+		if _, sumErr := Checksum(path); sumErr == nil {
+			return Header{}, err
+		}
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return Header{}, err
+	}
+
+	h, payload, err := splitHeader(path, kind, data)
+	if err != nil {
+		return Header{}, err
+	}
+	if h.Kind != kind {
+		return h, &CorruptError{Path: path, Reason: fmt.Sprintf("it holds %s instead of %s", h.Kind, kind)}
+	}
+	current := CurrentSchema(kind)
+	if h.Schema > current {
+		return h, &VersionError{Path: path, Header: h, Current: current}
+	}
+	if h.Schema == current {
+		return h, nil
+	}
+
+	for _, step := range migrations[kind][h.Schema:] {
+		if payload, err = step(payload); err != nil {
+			return h, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	upgraded := Header{Kind: kind, Schema: current, Producer: Producer()}
+	return h, Write(path, func(w io.Writer) error {
+		if err := writeHeader(w, upgraded); err != nil {
+			return err
+		}
+		_, err := w.Write(payload)
+		return err
+	})
+}
@@ -0,0 +1,142 @@
+package benchmark
+
+import (
+	"github.com/northberg/candlestick"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/db"
+	"pattern-evaluator/pkg/features"
+)
+
+type ReturnModel int
+
+const (
+	Raw ReturnModel = iota
+	MarketAdjusted
+	BetaAdjusted
+)
+
+const (
+	estimationWindow       = 250
+	minEstimationOverlap   = 60
+	benchmarkLookupRetries = 10
+)
+
+func (m ReturnModel) String() string {
+	switch m {
+	case Raw:
+		return "raw"
+	case MarketAdjusted:
+		return "market"
+	case BetaAdjusted:
+		return "beta"
+	default:
+		panic("undefined return model")
+	}
+}
+
+// Path follows the benchmark from the entry of a trade, scaled by the sensitivity of the symbol to the benchmark
+type Path struct {
+	beta       float64
+	entryPrice float64
+	lastReturn float64
+	interval   int64
+	collection []*candlestick.CandleSet
+}
+
+// NewPath prepares the benchmark path of a trade entered at the given time, the symbol candles are used to estimate beta.
+// There is no path when the benchmark has no candle at entry or beta cannot be estimated, the trade is then undefined
+func NewPath(model ReturnModel, entryTime int64, interval int64, symbolCandles []*candlestick.CandleSet) (*Path, bool) {
+	if model == Raw {
+		return &Path{}, true
+	}
+
+	collection := db.GetCandles(interval, candlestick.Interval1d, config.GetBenchmarkSymbol())
+
+	// The benchmark may lack a candle where the symbol has one, take the first one after entry instead
+	var entryCandle *candlestick.Candle
+	for i := int64(0); i < benchmarkLookupRetries; i++ {
+		entryCandle = db.CandleAtTimestamp(entryTime+i*interval, collection)
+		if entryCandle != nil {
+			break
+		}
+	}
+	if entryCandle == nil || entryCandle.Open == 0.0 {
+		return nil, false
+	}
+
+	// Without enough history to estimate beta the trade cannot be adjusted, rather than assuming it moves with the market
+	beta := 1.0
+	if model == BetaAdjusted {
+		b, ok := EstimateBeta(entryTime, interval, symbolCandles, collection)
+		if !ok {
+			return nil, false
+		}
+		beta = b
+	}
+
+	return &Path{
+		beta:       beta,
+		entryPrice: entryCandle.Open,
+		interval:   interval,
+		collection: collection,
+	}, true
+}
+
+// Return is the expected return of the symbol since entry given the benchmark close at the given time
+func (p *Path) Return(ts int64) float64 {
+	if p.beta == 0 {
+		return 0
+	}
+
+	// Carry the last known benchmark return forward over missing candles
+	c := db.CandleAtTimestamp(ts, p.collection)
+	if c != nil && c.Close != 0.0 {
+		p.lastReturn = c.Close/p.entryPrice - 1
+	}
+
+	return p.beta * p.lastReturn
+}
+
+// EstimateBeta regresses the daily returns of the symbol on those of the benchmark over the window before entry
+func EstimateBeta(entryTime int64, interval int64, symbolCandles []*candlestick.CandleSet, benchmarkCandles []*candlestick.CandleSet) (float64, bool) {
+	symbolHistory := features.LoadHistory(entryTime, estimationWindow+1, interval, symbolCandles)
+	benchmarkHistory := features.LoadHistory(entryTime, estimationWindow+1, interval, benchmarkCandles)
+
+	// Only days on which both have a return can be paired
+	benchmarkReturns := make(map[int64]float64)
+	for i := 1; i < len(benchmarkHistory.Candles); i++ {
+		prev, curr := benchmarkHistory.Candles[i-1], benchmarkHistory.Candles[i]
+		benchmarkReturns[curr.Time] = curr.Close/prev.Close - 1
+	}
+	xs := make([]float64, 0)
+	ys := make([]float64, 0)
+	for i := 1; i < len(symbolHistory.Candles); i++ {
+		prev, curr := symbolHistory.Candles[i-1], symbolHistory.Candles[i]
+		if x, ok := benchmarkReturns[curr.Time]; ok {
+			xs = append(xs, x)
+			ys = append(ys, curr.Close/prev.Close-1)
+		}
+	}
+	if len(xs) < minEstimationOverlap {
+		return 0, false
+	}
+
+	meanX, meanY := 0.0, 0.0
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(len(xs))
+	meanY /= float64(len(ys))
+
+	covariance, variance := 0.0, 0.0
+	for i := range xs {
+		covariance += (xs[i] - meanX) * (ys[i] - meanY)
+		variance += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	if variance == 0 {
+		return 0, false
+	}
+
+	return covariance / variance, true
+}
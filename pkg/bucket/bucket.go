@@ -5,21 +5,45 @@ import (
 	"github.com/godoji/algocore/pkg/algo"
 	"github.com/northberg/candlestick"
 	"log"
+	"pattern-evaluator/pkg/benchmark"
+	"pattern-evaluator/pkg/calendar"
+	"pattern-evaluator/pkg/config"
 	"pattern-evaluator/pkg/db"
 	"pattern-evaluator/pkg/evaluate"
+	"pattern-evaluator/pkg/regime"
 )
 
 type BucketMetrics struct {
-	Modified  bool
-	Undefined int
-	Buckets   map[int]int
-	SumReturn float64
+	Modified          bool
+	Undefined         int
+	Buckets           map[int]int
+	BucketsByYear     map[int]map[int]int
+	BucketsByQuarter  map[int]map[int]int
+	BucketsByMonth    map[int]map[int]int
+	BucketsByWeekday  map[int]map[int]int
+	BucketsByEarnings map[int]map[int]int
+	BucketsByRegime   map[string]map[int]int
+	SumReturn         float64
 }
 
-type Evaluator struct{}
+func newBucketMetrics() *BucketMetrics {
+	return &BucketMetrics{
+		Buckets:           make(map[int]int, 4),
+		BucketsByYear:     make(map[int]map[int]int),
+		BucketsByQuarter:  make(map[int]map[int]int),
+		BucketsByMonth:    make(map[int]map[int]int),
+		BucketsByWeekday:  make(map[int]map[int]int),
+		BucketsByEarnings: make(map[int]map[int]int),
+		BucketsByRegime:   make(map[string]map[int]int),
+	}
+}
+
+type Evaluator struct {
+	Returns benchmark.ReturnModel
+}
 
 func (e *Evaluator) Evaluate(params *evaluate.ParamSet, symbol string, events []*algo.Event) evaluate.Metrics {
-	return Evaluate(symbol, candlestick.Interval1d, events, params.Threshold, params.Timeout)
+	return Evaluate(symbol, candlestick.Interval1d, events, params.Threshold, params.Timeout, e.Returns)
 }
 
 func (qm BucketMetrics) Combine(other evaluate.Metrics) evaluate.Metrics {
@@ -27,30 +51,62 @@ func (qm BucketMetrics) Combine(other evaluate.Metrics) evaluate.Metrics {
 		log.Fatalln("cannot modify after emit")
 	}
 
-	combined := BucketMetrics{
-		Modified:  qm.Modified,
-		Undefined: qm.Undefined,
-		Buckets:   make(map[int]int),
-		SumReturn: qm.SumReturn,
-	}
+	combined := newBucketMetrics()
+	combined.Modified = qm.Modified
+	combined.Undefined = qm.Undefined
+	combined.SumReturn = qm.SumReturn
 
 	for i, v := range qm.Buckets {
 		combined.Buckets[i] = v
 	}
+	qm.addBreakdowns(combined)
 
-	if otherMetrics, ok := other.(*BucketMetrics); ok {
-		for i := 0; i < 4; i++ {
-			combined.Buckets[i] += otherMetrics.Buckets[i]
-		}
-	} else if otherMetrics, ok := other.(BucketMetrics); ok {
-		for i := 0; i < 4; i++ {
-			combined.Buckets[i] += otherMetrics.Buckets[i]
-		}
+	var otherMetrics BucketMetrics
+	if m, ok := other.(*BucketMetrics); ok {
+		otherMetrics = *m
+	} else if m, ok := other.(BucketMetrics); ok {
+		otherMetrics = m
 	} else {
 		panic("cannot not add other type than BucketMetrics")
 	}
 
-	return combined
+	for i := 0; i < 4; i++ {
+		combined.Buckets[i] += otherMetrics.Buckets[i]
+	}
+	otherMetrics.addBreakdowns(combined)
+
+	return *combined
+}
+
+func (qm BucketMetrics) addBreakdowns(dst *BucketMetrics) {
+	for _, dimension := range calendar.Dimensions() {
+		evaluate.AddBreakdown(dst.breakdown(dimension), qm.breakdown(dimension), evaluate.AddCounts[int])
+	}
+	evaluate.AddBreakdown(dst.BucketsByRegime, qm.BucketsByRegime, evaluate.AddCounts[int])
+}
+
+// fromBuckets holds the counts of a single period or regime
+func fromBuckets(buckets map[int]int) evaluate.Metrics {
+	m := newBucketMetrics()
+	for i, v := range buckets {
+		m.Buckets[i] = v
+	}
+	return *m
+}
+
+func (qm BucketMetrics) ForRegime(r string) evaluate.Metrics {
+	m := fromBuckets(qm.BucketsByRegime[r]).(BucketMetrics)
+	m.BucketsByRegime[r] = m.Buckets
+	return m
+}
+
+func (qm BucketMetrics) breakdown(dimension string) map[int]map[int]int {
+	return calendar.Pick(dimension, qm.BucketsByYear, qm.BucketsByQuarter, qm.BucketsByMonth, qm.BucketsByWeekday, qm.BucketsByEarnings)
+}
+
+// ByPeriod splits the metrics into the metrics of every period of a calendar dimension
+func (qm BucketMetrics) ByPeriod(dimension string) map[int]evaluate.Metrics {
+	return evaluate.SplitBreakdown(qm.breakdown(dimension), fromBuckets)
 }
 
 func (qm BucketMetrics) Evaluator() string {
@@ -77,6 +133,10 @@ func (qm BucketMetrics) String() string {
 	return output
 }
 
+func (qm BucketMetrics) Outcomes() (int, int) {
+	return qm.GetBucket(3), qm.GetBucket(0)
+}
+
 func (qm BucketMetrics) Value() float64 {
 	return evaluate.Performance(qm.GetBucket(3), qm.GetBucket(0))
 }
@@ -104,22 +164,12 @@ func (qm BucketMetrics) Emit(key string) float64 {
 	}
 }
 
-func findOutcome(event *algo.Event, threshold float64, timeout int64, interval int64, collection []*candlestick.CandleSet) (float64, bool) {
-
-	// First point in time, where we have knowledge of the event
-	bookTime := event.Time + interval
+func findOutcome(event *algo.Event, threshold float64, timeout int64, interval int64, collection []*candlestick.CandleSet, model benchmark.ReturnModel) (float64, int64, bool) {
 
 	// Find the candle at the start time, or after the start time if no candle was available
-	var startCandle *candlestick.Candle
-	for i := int64(0); i < 10; i++ {
-		entryCandle := db.CandleAtTimestamp(bookTime+i*interval, collection)
-		if entryCandle != nil {
-			startCandle = entryCandle
-			break
-		}
-	}
+	startCandle := db.EntryCandle(event.Time, interval, collection)
 	if startCandle == nil || startCandle.Open == 0.0 {
-		return 0, false
+		return 0, 0, false
 	}
 
 	// The entry price of our trade would be at the opening of the start candle
@@ -128,13 +178,16 @@ func findOutcome(event *algo.Event, threshold float64, timeout int64, interval i
 	// The time at which we entered the trade
 	startTime := startCandle.Time
 
-	// Determine the upper and lower barrier
-	upperBarrier := entryPrice * (1.0 + threshold)
-	lowerBarrier := entryPrice * (1.0 - threshold)
+	// The part of the return we expect from the benchmark alone, zero when measuring raw returns
+	path, ok := benchmark.NewPath(model, startTime, interval, collection)
+	if !ok {
+		return 0, 0, false
+	}
 
 	// Make sure that we don't hit the time limit because of a missing candle, we still accept an exit at the first available
 	missing := 0
 	lastCandle := startCandle
+	lastExpected := 0.0
 
 	for i := int64(0); i < timeout; i++ {
 		currentCandle := db.CandleAtTimestamp(startTime+i*interval, collection)
@@ -148,48 +201,58 @@ func findOutcome(event *algo.Event, threshold float64, timeout int64, interval i
 		}
 
 		lastCandle = currentCandle
-		low := currentCandle.Low
-		high := currentCandle.High
+		lastExpected = path.Return(currentCandle.Time)
 
-		if low <= lowerBarrier {
-			profit := (lowerBarrier - startCandle.Open) / startCandle.Open
-			return profit, true
+		// The barriers are placed on the excess return path, which is the raw path when there is no benchmark
+		low := (currentCandle.Low-entryPrice)/entryPrice - lastExpected
+		high := (currentCandle.High-entryPrice)/entryPrice - lastExpected
+
+		if low <= -threshold {
+			return -threshold, startTime, true
 		}
-		if high >= upperBarrier {
-			profit := (upperBarrier - startCandle.Open) / startCandle.Open
-			return profit, true
+		if high >= threshold {
+			return threshold, startTime, true
 		}
 	}
 
 	// Fall back on the last seen candle
-	return (lastCandle.Close - startCandle.Open) / startCandle.Open, true
+	return (lastCandle.Close-entryPrice)/entryPrice - lastExpected, startTime, true
+}
+
+func bucketOf(exit float64, threshold float64) (int, bool) {
+	if exit > 0 && exit < threshold/2 {
+		return 2, true
+	} else if exit < 0 && exit > -threshold/2 {
+		return 1, true
+	} else if exit > 0 {
+		return 3, true
+	} else if exit < 0 {
+		return 0, true
+	}
+	return 0, false
 }
 
-func Evaluate(symbol string, interval int64, events []*algo.Event, threshold float64, timeout int64) *BucketMetrics {
+func Evaluate(symbol string, interval int64, events []*algo.Event, threshold float64, timeout int64, model benchmark.ReturnModel) *BucketMetrics {
 
 	collection := db.GetCandles(interval, candlestick.Interval1d, symbol)
 
-	m := &BucketMetrics{
-		Modified:  false,
-		Undefined: 0,
-		Buckets:   make(map[int]int, 4),
-		SumReturn: 0,
-	}
+	m := newBucketMetrics()
+	earningsWindow := config.GetEarningsWindow()
 
 	for _, event := range events {
-		exit, ok := findOutcome(event, threshold, timeout, interval, collection)
+		exit, entryTime, ok := findOutcome(event, threshold, timeout, interval, collection, model)
 
 		if ok {
 			m.SumReturn += exit
-			if exit > 0 && exit < threshold/2 {
-				m.Buckets[2]++
-			} else if exit < 0 && exit > -threshold/2 {
-				m.Buckets[1]++
-			} else if exit > 0 {
-				m.Buckets[3]++
-			} else if exit < 0 {
-				m.Buckets[0]++
+			b, ok := bucketOf(exit, threshold)
+			if !ok {
+				continue
+			}
+			m.Buckets[b]++
+			for dimension, key := range calendar.EventPeriods(event.Time, entryTime, earningsWindow) {
+				evaluate.CountIn(m.breakdown(dimension), key, b)
 			}
+			evaluate.CountIn(m.BucketsByRegime, regime.Default().Classify(event.Time+interval), b)
 		} else {
 			m.Undefined++
 		}
@@ -0,0 +1,112 @@
+package calendar
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	Year     = "year"
+	Month    = "month"
+	Weekday  = "weekday"
+	Quarter  = "quarter"
+	Earnings = "earnings"
+)
+
+// Most companies report in the weeks following the middle of the first month of a quarter
+const earningsSeasonDay = 15
+
+const daySeconds = 24 * 60 * 60
+
+// Dimensions lists every calendar breakdown in the order they are reported
+func Dimensions() []string {
+	return []string{Year, Quarter, Month, Weekday, Earnings}
+}
+
+// Period returns the key of the period the timestamp falls in, earnings periods only exist within the window around a season
+func Period(dimension string, ts int64, earningsWindow int) (int, bool) {
+	t := time.Unix(ts, 0).UTC()
+	switch dimension {
+	case Year:
+		return t.Year(), true
+	case Quarter:
+		return (int(t.Month())-1)/3 + 1, true
+	case Month:
+		return int(t.Month()), true
+	case Weekday:
+		return int(t.Weekday()), true
+	case Earnings:
+		return EarningsWeek(ts, earningsWindow)
+	default:
+		panic("undefined calendar dimension")
+	}
+}
+
+// Pick returns the breakdown of the dimension, given the breakdowns of every dimension in the order of Dimensions
+func Pick[T any](dimension string, breakdowns ...T) T {
+	for i, d := range Dimensions() {
+		if d == dimension {
+			return breakdowns[i]
+		}
+	}
+	panic("undefined calendar dimension")
+}
+
+// EventPeriods returns the key of every period an event falls in by dimension. The weekday is the day we would
+// enter, all other periods are those in which the pattern occurred
+func EventPeriods(eventTime int64, entryTime int64, earningsWindow int) map[string]int {
+	periods := make(map[string]int)
+	for _, dimension := range Dimensions() {
+		ts := eventTime
+		if dimension == Weekday {
+			ts = entryTime
+		}
+		if key, ok := Period(dimension, ts, earningsWindow); ok {
+			periods[dimension] = key
+		}
+	}
+	return periods
+}
+
+// EarningsWeek is the number of weeks between the timestamp and the start of the nearest earnings season
+func EarningsWeek(ts int64, window int) (int, bool) {
+	t := time.Unix(ts, 0).UTC()
+	nearest := math.MaxInt64
+	for _, year := range []int{t.Year() - 1, t.Year(), t.Year() + 1} {
+		for _, month := range []time.Month{time.January, time.April, time.July, time.October} {
+			start := time.Date(year, month, earningsSeasonDay, 0, 0, 0, 0, time.UTC)
+			days := int((ts - start.Unix()) / daySeconds)
+			if abs(days) < abs(nearest) {
+				nearest = days
+			}
+		}
+	}
+	if abs(nearest) > window {
+		return 0, false
+	}
+	return int(math.Floor(float64(nearest) / 7)), true
+}
+
+// Label formats a period key for reports
+func Label(dimension string, key int) string {
+	switch dimension {
+	case Quarter:
+		return fmt.Sprintf("Q%d", key)
+	case Month:
+		return time.Month(key).String()[:3]
+	case Weekday:
+		return time.Weekday(key).String()[:3]
+	case Earnings:
+		return fmt.Sprintf("wk%+d", key)
+	default:
+		return fmt.Sprintf("%d", key)
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,206 @@
+package chart
+
+import (
+	"fmt"
+	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"pattern-evaluator/pkg/artifact"
+)
+
+// FontPath points at the TrueType font used for all text
+var FontPath = "./assets/fonts/Helvetica.ttf"
+
+const (
+	fontSize    = 34
+	barWidth    = 120
+	barSpacing  = 24
+	plotHeight  = 600
+	titleOffset = 100
+	labelOffset = 80
+)
+
+var (
+	axisColor      = color.RGBA{R: 120, G: 120, B: 120, A: 255}
+	referenceColor = color.RGBA{R: 255, G: 63, B: 52, A: 255}
+	barColor       = color.RGBA{R: 11, G: 232, B: 129, A: 255}
+)
+
+func DrawText(ctx *freetype.Context, text string, x, y int) {
+	pt := freetype.Pt(x, y)
+	_, err := ctx.DrawString(text, pt)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func LoadFont() *truetype.Font {
+
+	fontData, err := os.ReadFile(FontPath)
+	if err != nil {
+		panic(err)
+	}
+
+	ttf, err := truetype.Parse(fontData)
+	if err != nil {
+		panic(err)
+	}
+
+	return ttf
+}
+
+// NewContext prepares a freetype context drawing black text onto the image
+func NewContext(img *image.RGBA) *freetype.Context {
+	ctx := freetype.NewContext()
+	ctx.SetDst(img)
+	ctx.SetClip(img.Bounds())
+	ctx.SetSrc(image.Black)
+	ctx.SetFont(LoadFont())
+	ctx.SetFontSize(fontSize)
+	return ctx
+}
+
+func SavePNG(img image.Image, outPath string) {
+	err := artifact.Write(outPath, func(w io.Writer) error {
+		return png.Encode(w, img)
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Bounds spans the values along with zero, leaving out gaps, so that charts of any metric fit their data
+func Bounds(values []float64) (float64, float64) {
+	min, max := 0.0, 0.0
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			min, max = math.Min(min, v), math.Max(max, v)
+		}
+	}
+	if min == max {
+		max = min + 1
+	}
+	return min, max
+}
+
+// toY maps a value between min and max onto the vertical pixel position within the plot area
+func toY(v float64, min float64, max float64) int {
+	rel := math.Max(0, math.Min((v-min)/(max-min), 1.0))
+	return titleOffset + plotHeight - int(rel*float64(plotHeight-fontSize))
+}
+
+// BarChart draws one bar per label from zero, scaled between min and max, with a horizontal line at the reference value
+func BarChart(title string, labels []string, values []float64, min float64, max float64, reference float64) *image.RGBA {
+
+	imageWidth := barSpacing + len(labels)*(barWidth+barSpacing)
+	imageHeight := titleOffset + plotHeight + labelOffset
+	img := image.NewRGBA(image.Rect(0, 0, imageWidth, imageHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+
+	ctx := NewContext(img)
+	DrawText(ctx, title, barSpacing, titleOffset/2+fontSize/2)
+
+	baseline := titleOffset + plotHeight
+	zero := toY(0, min, max)
+
+	ctx.SetFontSize(fontSize / 8 * 5)
+	for i, label := range labels {
+		x := barSpacing + i*(barWidth+barSpacing)
+		if !math.IsNaN(values[i]) {
+			top := toY(values[i], min, max)
+			draw.Draw(img, image.Rect(x, top, x+barWidth, zero), &image.Uniform{C: barColor}, image.Point{}, draw.Src)
+			DrawText(ctx, fmt.Sprintf("%.1f", values[i]), x+barWidth/6, int(math.Min(float64(top), float64(zero)))-fontSize/4)
+		}
+		DrawText(ctx, label, x+barWidth/6, baseline+labelOffset/2+fontSize/4)
+	}
+
+	// Axis and reference line are drawn last so that they stay visible on top of the bars
+	draw.Draw(img, image.Rect(0, baseline, imageWidth, baseline+2), &image.Uniform{C: axisColor}, image.Point{}, draw.Src)
+	ref := toY(reference, min, max)
+	draw.Draw(img, image.Rect(0, ref, imageWidth, ref+2), &image.Uniform{C: referenceColor}, image.Point{}, draw.Src)
+
+	return img
+}
+
+type Series struct {
+	Name   string
+	Values []float64
+}
+
+var seriesColors = []color.RGBA{
+	{R: 11, G: 232, B: 129, A: 255},
+	{R: 52, G: 120, B: 246, A: 255},
+	{R: 255, G: 159, B: 26, A: 255},
+	{R: 155, G: 89, B: 182, A: 255},
+}
+
+// LineChart draws every series as a line over the labels, scaled between min and max, with a horizontal line at the reference value
+func LineChart(title string, labels []string, series []Series, min float64, max float64, reference float64) *image.RGBA {
+
+	imageWidth := barSpacing + len(labels)*(barWidth+barSpacing)
+	imageHeight := titleOffset + plotHeight + labelOffset
+	img := image.NewRGBA(image.Rect(0, 0, imageWidth, imageHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+
+	ctx := NewContext(img)
+	DrawText(ctx, title, barSpacing, titleOffset/2+fontSize/2)
+
+	baseline := titleOffset + plotHeight
+	toX := func(i int) int {
+		return barSpacing + i*(barWidth+barSpacing) + barWidth/2
+	}
+
+	draw.Draw(img, image.Rect(0, baseline, imageWidth, baseline+2), &image.Uniform{C: axisColor}, image.Point{}, draw.Src)
+	ref := toY(reference, min, max)
+	draw.Draw(img, image.Rect(0, ref, imageWidth, ref+2), &image.Uniform{C: referenceColor}, image.Point{}, draw.Src)
+
+	ctx.SetFontSize(fontSize / 8 * 5)
+	for i, label := range labels {
+		DrawText(ctx, label, toX(i)-barWidth/3, baseline+labelOffset/2+fontSize/4)
+	}
+
+	for s, line := range series {
+		c := &image.Uniform{C: seriesColors[s%len(seriesColors)]}
+
+		// Gaps in a series are left open instead of being bridged
+		for i := 1; i < len(line.Values); i++ {
+			if math.IsNaN(line.Values[i-1]) || math.IsNaN(line.Values[i]) {
+				continue
+			}
+			drawLine(img, toX(i-1), toY(line.Values[i-1], min, max), toX(i), toY(line.Values[i], min, max), c)
+		}
+		for i, v := range line.Values {
+			if !math.IsNaN(v) {
+				y := toY(v, min, max)
+				draw.Draw(img, image.Rect(toX(i)-5, y-5, toX(i)+5, y+5), c, image.Point{}, draw.Src)
+			}
+		}
+
+		// Legend in the top right corner, or from the left on charts too narrow to leave room for it
+		legendX := imageWidth - 4*barWidth
+		if legendX < barSpacing {
+			legendX = barSpacing
+		}
+		legendY := titleOffset/2 + s*fontSize
+		draw.Draw(img, image.Rect(legendX, legendY-fontSize/3, legendX+fontSize/2, legendY+fontSize/6), c, image.Point{}, draw.Src)
+		DrawText(ctx, line.Name, legendX+fontSize, legendY+fontSize/6)
+	}
+
+	return img
+}
+
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c *image.Uniform) {
+	steps := int(math.Max(math.Abs(float64(x1-x0)), math.Abs(float64(y1-y0))))
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / math.Max(float64(steps), 1)
+		x := x0 + int(math.Round(t*float64(x1-x0)))
+		y := y0 + int(math.Round(t*float64(y1-y0)))
+		draw.Draw(img, image.Rect(x-1, y-1, x+2, y+2), c, image.Point{}, draw.Src)
+	}
+}
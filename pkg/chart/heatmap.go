@@ -0,0 +1,163 @@
+package chart
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"pattern-evaluator/pkg/evaluate"
+	"sort"
+)
+
+const (
+	cellTargetWidth  = 180
+	cellTargetHeight = 92
+	borderWidth      = 2
+)
+
+var cellBackground = color.RGBA{R: 230, G: 230, B: 230, A: 255}
+
+func interpolate(value1, value2 uint8, factor float64) uint8 {
+	if factor <= 0 {
+		return value1
+	}
+	if factor >= 1 {
+		return value2
+	}
+
+	result := float64(value1)*(1-factor) + float64(value2)*factor
+	return uint8(math.Round(result))
+}
+
+func interpolateColor(value float64) color.RGBA {
+	if value < 0.5 {
+		normalized := ((0.5 - value) / 0.5) * 5
+		return color.RGBA{
+			R: interpolate(255, 255, normalized),
+			G: interpolate(255, 63, normalized),
+			B: interpolate(255, 52, normalized),
+			A: 255,
+		}
+	} else {
+		normalized := ((value - 0.5) / 0.5) * 5
+		return color.RGBA{
+			R: interpolate(255, 11, normalized),
+			G: interpolate(255, 232, normalized),
+			B: interpolate(255, 129, normalized),
+			A: 255,
+		}
+	}
+}
+
+// Heatmap draws the table with the emitted value of the key in every cell, coloured by performance or by size
+func Heatmap(title string, table *evaluate.MetricsTable, key string) *image.RGBA {
+
+	matrix := table.Values
+
+	// Determine matrix dimensions, a table without rows is drawn as its title alone on a few empty columns
+	rows := len(matrix) + 1
+	cols := 4
+	if len(matrix) > 0 {
+		cols = len(matrix[0]) + 1
+	}
+
+	// Create an empty image
+	imageWidth := cellTargetWidth*cols + borderWidth*(cols+1)
+	imageHeight := cellTargetHeight*rows + borderWidth*(rows+1) + titleOffset
+	img := image.NewRGBA(image.Rect(0, 0, imageWidth, imageHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.White}, image.Point{}, draw.Src)
+
+	// Calculate cell width and height
+	cellWidth := float64(cellTargetWidth)
+	cellHeight := float64(cellTargetHeight)
+
+	// Create a freetype context for drawing text
+	ctx := NewContext(img)
+
+	DrawText(ctx, title, borderWidth+int(cellWidth+float64(borderWidth)), int(cellHeight/2)+fontSize/2)
+	if len(matrix) == 0 {
+		return img
+	}
+
+	ctx.SetFontSize(fontSize / 8 * 7)
+
+	for y := 1; y < rows; y++ {
+		cellY := titleOffset + borderWidth + int(float64(y)*cellHeight+float64(y*borderWidth))
+		draw.Draw(img, image.Rect(borderWidth, cellY, borderWidth+int(cellWidth), cellY+int(cellHeight)), &image.Uniform{C: cellBackground}, image.Point{}, draw.Src)
+		textX := fontSize / 2
+		textY := cellY + int(cellHeight/2) + fontSize/2
+		DrawText(ctx, table.Rows[y-1], textX, textY)
+	}
+
+	for x := 1; x < cols; x++ {
+		cellX := borderWidth + int(float64(x)*cellWidth+float64(x*borderWidth))
+		draw.Draw(img, image.Rect(cellX, titleOffset+borderWidth, cellX+int(cellWidth), titleOffset+borderWidth+int(cellHeight)), &image.Uniform{C: cellBackground}, image.Point{}, draw.Src)
+		textX := cellX + fontSize/2
+		textY := titleOffset + int(cellHeight/2) + fontSize/2
+		DrawText(ctx, table.Columns[x-1], textX, textY)
+	}
+
+	maxSize := 0
+	totalEntries := make([]int, 0)
+	if key == "size" {
+		for y := 1; y < rows; y++ {
+			for x := 1; x < cols; x++ {
+				val := matrix[y-1][x-1]
+				s := 0
+				if val != nil {
+					s = val.Size()
+				}
+				if s > maxSize {
+					maxSize = s
+				}
+				totalEntries = append(totalEntries, s)
+			}
+		}
+		sort.Ints(totalEntries)
+	}
+
+	// Draw heatmap
+	for y := 1; y < rows; y++ {
+		for x := 1; x < cols; x++ {
+
+			// Calculate cell position
+			cellX := borderWidth + int(float64(x)*cellWidth+float64(x*borderWidth))
+			cellY := titleOffset + borderWidth + int(float64(y)*cellHeight+float64(y*borderWidth))
+
+			val := matrix[y-1][x-1]
+			if val == nil {
+				continue
+			}
+
+			// Set the cell color in the image
+			cellColor := interpolateColor(val.Value())
+			if key == "size" {
+				// green: rgb(11, 232, 129)
+				// red: rgb(255, 63, 52)
+				ref := totalEntries[len(totalEntries)/2]
+				rel := math.Min(float64(val.Size())/float64(ref), 1.0)
+				cellColor = color.RGBA{
+					R: interpolate(255, 11, rel),
+					G: interpolate(63, 232, rel),
+					B: interpolate(52, 129, rel),
+					A: 255,
+				}
+			}
+			draw.Draw(img, image.Rect(cellX, cellY, cellX+int(cellWidth), cellY+int(cellHeight)), &image.Uniform{C: cellColor}, image.Point{}, draw.Src)
+
+			// Draw the value text within the cell
+			ctx.SetFontSize(fontSize)
+			textX := cellX + fontSize/2
+			textY := cellY + int(cellHeight/2) + fontSize/2
+
+			if key != "size" {
+				DrawText(ctx, fmt.Sprintf("%.2f", val.Emit(key)), textX, textY)
+			} else {
+				DrawText(ctx, fmt.Sprintf("%d", val.Size()), textX, textY)
+			}
+		}
+	}
+
+	return img
+}
@@ -1,126 +1,151 @@
 package config
 
 import (
-	"bufio"
-	"github.com/godoji/algocore/pkg/kiosk"
+	"fmt"
 	"os"
 	"pattern-evaluator/pkg/evaluate"
 	"strconv"
 	"strings"
 )
 
-var algoList = []string{"double-top", "double-bottom", "random", "triple-top", "triple-bottom", "head-and-shoulders"}
 var evalList = []string{"3b", "fixed", "bucket"}
 
+const defaultBenchmark = "UNICORN:US:SPY"
+const defaultEarningsWindow = 21
+
 type EvalParams struct {
-	Thresholds   []float64
-	TimeLimits   []int64
-	HighLowRange []float64
-	HighLowTest  []float64
+	Thresholds      []float64
+	TimeLimits      []int64
+	Params          []Dimension
+	AlgorithmParams map[string][]Dimension
+}
+
+func GetBenchmarkSymbol() string {
+	if v := os.Getenv("BENCHMARK_SYMBOL"); v != "" {
+		return v
+	}
+	return defaultBenchmark
+}
+
+// GetEarningsWindow is the number of days around the start of an earnings season for which events are broken down
+func GetEarningsWindow() int {
+	if v := os.Getenv("EARNINGS_WINDOW"); v != "" {
+		if days, err := strconv.Atoi(v); err == nil {
+			return days
+		}
+	}
+	return defaultEarningsWindow
 }
 
-func GetAlgoList() []string {
-	return algoList
+// GetCandleVersion identifies the candle data the events were evaluated on, outputs are rebuilt when it changes. Kiosk
+// does not version its candles, so CANDLE_VERSION has to be bumped by hand whenever they are refreshed
+func GetCandleVersion() (string, error) {
+	if v := os.Getenv("CANDLE_VERSION"); v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("CANDLE_VERSION is not set, set it and change it whenever the candles on kiosk are refreshed")
 }
 
-func GetSymbolList() ([]string, error) {
-	info, err := kiosk.GetExchangeInfo()
+func LoadCombinations(filename string, algoName string) ([]evaluate.ParamSet, error) {
+	grid, err := LoadEvaluationParameters(filename)
 	if err != nil {
 		return nil, err
 	}
-	symbols := make([]string, 0)
-	for _, exchange := range info.Exchanges {
-		if exchange.ExchangeId == "US" {
-			for symbol := range exchange.Symbols {
-				if symbol == "UNICORN:US:ZNH" {
-					continue
-				}
-				symbols = append(symbols, symbol)
+	return grid.ForAlgorithm(algoName).Combinations(), nil
+}
+
+// Combinations lists every threshold, timeout and parameter vector of the grid
+func (p *EvalParams) Combinations() []evaluate.ParamSet {
+	var combinations []evaluate.ParamSet
+	for _, threshold := range p.Thresholds {
+		for _, timeout := range p.TimeLimits {
+			for _, vector := range p.ParamVectors() {
+				combinations = append(combinations, evaluate.ParamSet{Threshold: threshold, Timeout: timeout, Params: vector})
 			}
 		}
 	}
-	return symbols, nil
+	return combinations
 }
 
-func LoadCombinations(filename string) ([]evaluate.ParamSet, error) {
-	params, err := LoadEvaluationParameters(filename)
-	if err != nil {
-		return nil, err
+// ForAlgorithm narrows the grid down to the parameters of a single algorithm, a section for the algorithm replaces the shared parameters
+func (p *EvalParams) ForAlgorithm(algoName string) *EvalParams {
+	params := p.Params
+	if dimensions, ok := p.AlgorithmParams[algoName]; ok {
+		params = dimensions
 	}
-	var combinations []evaluate.ParamSet
-	for _, threshold := range params.Thresholds {
-		for _, timeout := range params.TimeLimits {
-			for _, p1 := range params.HighLowRange {
-				combinations = append(combinations, evaluate.ParamSet{Threshold: threshold, Timeout: timeout, Params: []float64{p1}})
+	return &EvalParams{
+		Thresholds:      p.Thresholds,
+		TimeLimits:      p.TimeLimits,
+		Params:          params,
+		AlgorithmParams: map[string][]Dimension{algoName: params},
+	}
+}
+
+// ParamNames formats a parameter vector as "name:value" pairs of the declared algorithm parameters
+func (p *EvalParams) ParamNames(vector []float64) string {
+	names := make([]string, len(vector))
+	for i, v := range vector {
+		if i < len(p.Params) {
+			names[i] = fmt.Sprintf("%s:%g", p.Params[i].Name, v)
+		} else {
+			names[i] = fmt.Sprintf("%g", v)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// ParamVectors expands the cartesian product of all algorithm parameters, in the order in which they were declared
+func (p *EvalParams) ParamVectors() [][]float64 {
+	vectors := [][]float64{{}}
+	for _, dimension := range p.Params {
+		expanded := make([][]float64, 0, len(vectors)*len(dimension.Values))
+		for _, vector := range vectors {
+			for _, v := range dimension.Values {
+				next := make([]float64, len(vector), len(vector)+1)
+				copy(next, vector)
+				expanded = append(expanded, append(next, v))
 			}
 		}
+		vectors = expanded
 	}
-	return combinations, nil
+	return vectors
 }
 
+// LoadEvaluationParameters reads a parameter grid, every dimension besides the threshold and timeout is an algorithm parameter,
+// shared by all algorithms unless it is declared in the section of an algorithm
 func LoadEvaluationParameters(filename string) (*EvalParams, error) {
-	file, err := os.Open(filename)
+	dimensions, err := LoadGrid(filename)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
-
-	var thresholds []float64
-	var timeLimits []int64
-	var highLowRange []float64
-	var highLowTest []float64
-
-	scanner := bufio.NewScanner(file)
-	lineNumber := 0
-	for scanner.Scan() {
-		line := scanner.Text()
-		fields := strings.Fields(line)
-
-		switch lineNumber {
-		case 0:
-			for _, field := range fields {
-				val, err := strconv.ParseFloat(field, 64)
-				if err != nil {
-					return nil, err
-				}
-				thresholds = append(thresholds, val)
-			}
-		case 1:
-			for _, field := range fields {
-				val, err := strconv.ParseInt(field, 10, 64)
-				if err != nil {
-					return nil, err
-				}
-				timeLimits = append(timeLimits, val)
-			}
-		case 2:
-			for _, field := range fields {
-				val, err := strconv.ParseFloat(field, 64)
-				if err != nil {
-					return nil, err
-				}
-				highLowRange = append(highLowRange, val)
-			}
-		case 3:
-			for _, field := range fields {
-				val, err := strconv.ParseFloat(field, 64)
-				if err != nil {
-					return nil, err
-				}
-				highLowTest = append(highLowTest, val)
+
+	params := &EvalParams{
+		Params:          make([]Dimension, 0),
+		AlgorithmParams: make(map[string][]Dimension),
+	}
+	for _, dimension := range dimensions {
+		if dimension.Algorithm != "" {
+			params.AlgorithmParams[dimension.Algorithm] = append(params.AlgorithmParams[dimension.Algorithm], dimension)
+			continue
+		}
+		switch dimension.Name {
+		case ThresholdDimension:
+			params.Thresholds = dimension.Values
+		case TimeoutDimension:
+			for _, v := range dimension.Values {
+				params.TimeLimits = append(params.TimeLimits, int64(v))
 			}
+		default:
+			params.Params = append(params.Params, dimension)
 		}
-		lineNumber++
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	if params.Thresholds == nil {
+		return nil, fmt.Errorf("%s: no %q dimension declared", filename, ThresholdDimension)
+	}
+	if params.TimeLimits == nil {
+		return nil, fmt.Errorf("%s: no %q dimension declared", filename, TimeoutDimension)
 	}
 
-	return &EvalParams{
-		Thresholds:   thresholds,
-		TimeLimits:   timeLimits,
-		HighLowRange: highLowRange,
-		HighLowTest:  highLowTest,
-	}, nil
+	return params, nil
 }
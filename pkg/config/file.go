@@ -0,0 +1,89 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// entry is a single "key = value" line of a configuration file, within the section of the last "[section]" header
+type entry struct {
+	Section string
+	Key     string
+	Value   string
+	Line    int
+}
+
+// lineError points at the line of the configuration file that could not be understood
+func lineError(filename string, line int, format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s", filename, line, fmt.Sprintf(format, args...))
+}
+
+// readEntries reads all "key = value" lines of a file, ignoring blank lines and everything after a '#'
+func readEntries(filename string) ([]entry, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := make([]entry, 0)
+	seen := make(map[string]int)
+	section := ""
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || len(line) < 3 {
+				return nil, lineError(filename, lineNumber, "malformed section header %q", line)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		i := strings.Index(line, "=")
+		if i == -1 {
+			return nil, lineError(filename, lineNumber, "expected \"key = value\", got %q", line)
+		}
+		key := strings.TrimSpace(line[:i])
+		if key == "" {
+			return nil, lineError(filename, lineNumber, "missing key before '='")
+		}
+		if prev, ok := seen[section+"."+key]; ok {
+			return nil, lineError(filename, lineNumber, "%q is already declared on line %d", key, prev)
+		}
+		seen[section+"."+key] = lineNumber
+
+		entries = append(entries, entry{
+			Section: section,
+			Key:     key,
+			Value:   strings.TrimSpace(line[i+1:]),
+			Line:    lineNumber,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// fields splits a value on whitespace and commas
+func fields(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
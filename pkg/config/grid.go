@@ -0,0 +1,95 @@
+package config
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	ThresholdDimension = "threshold"
+	TimeoutDimension   = "timeout"
+)
+
+// Values produced by a range are rounded, so that 0.015:0.05:0.005 yields 0.02 and not 0.020000000000000004
+const rangePrecision = 1e10
+
+// Dimension is a named list of values the evaluation is repeated for, algorithm parameters may belong to a single algorithm
+type Dimension struct {
+	Algorithm string
+	Name      string
+	Values    []float64
+	Line      int
+}
+
+// parseValues reads a list of numbers, where each item is either a number or an inclusive range "start:stop:step"
+func parseValues(filename string, e entry) ([]float64, error) {
+	values := make([]float64, 0)
+	for _, field := range fields(e.Value) {
+		if !strings.Contains(field, ":") {
+			val, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, lineError(filename, e.Line, "%s: %q is not a number", e.Key, field)
+			}
+			values = append(values, val)
+			continue
+		}
+
+		parts := strings.Split(field, ":")
+		if len(parts) != 3 {
+			return nil, lineError(filename, e.Line, "%s: range %q must be written as start:stop:step", e.Key, field)
+		}
+		bounds := make([]float64, 3)
+		for i, part := range parts {
+			val, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return nil, lineError(filename, e.Line, "%s: %q in range %q is not a number", e.Key, part, field)
+			}
+			bounds[i] = val
+		}
+		start, stop, step := bounds[0], bounds[1], bounds[2]
+		if step <= 0 {
+			return nil, lineError(filename, e.Line, "%s: step of range %q must be positive", e.Key, field)
+		}
+		if stop < start {
+			return nil, lineError(filename, e.Line, "%s: range %q ends before it starts", e.Key, field)
+		}
+		n := int(math.Floor((stop-start)/step+1e-9)) + 1
+		for i := 0; i < n; i++ {
+			values = append(values, math.Round((start+float64(i)*step)*rangePrecision)/rangePrecision)
+		}
+	}
+	if len(values) == 0 {
+		return nil, lineError(filename, e.Line, "%s: no values given", e.Key)
+	}
+	return values, nil
+}
+
+// LoadGrid reads every dimension declared in a parameter grid file, in the order of declaration
+func LoadGrid(filename string) ([]Dimension, error) {
+	entries, err := readEntries(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	dimensions := make([]Dimension, 0)
+	for _, e := range entries {
+		values, err := parseValues(filename, e)
+		if err != nil {
+			return nil, err
+		}
+		if e.Section != "" && (e.Key == ThresholdDimension || e.Key == TimeoutDimension) {
+			return nil, lineError(filename, e.Line, "%s cannot be declared for a single algorithm", e.Key)
+		}
+		if e.Key == TimeoutDimension {
+			for _, v := range values {
+				if v != math.Trunc(v) || v <= 0 {
+					return nil, lineError(filename, e.Line, "%s: %v is not a positive whole number of candles", e.Key, v)
+				}
+			}
+		}
+		dimensions = append(dimensions, Dimension{Algorithm: e.Section, Name: e.Key, Values: values, Line: e.Line})
+	}
+
+	return dimensions, nil
+}
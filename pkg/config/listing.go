@@ -0,0 +1,174 @@
+package config
+
+import (
+	"encoding/csv"
+	"github.com/godoji/algocore/pkg/algo"
+	"github.com/godoji/algocore/pkg/kiosk"
+	"github.com/northberg/candlestick"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const listingDateLayout = "2006-01-02"
+
+// Listing is the period in which a symbol could be traded, zero means the date is unknown or still to come
+type Listing struct {
+	Listed   int64
+	Delisted int64
+}
+
+func (l Listing) ListedAt(ts int64) bool {
+	if l.Listed != 0 && ts < l.Listed {
+		return false
+	}
+	if l.Delisted != 0 && ts >= l.Delisted {
+		return false
+	}
+	return true
+}
+
+// readListingFile reads "symbol,listed,delisted" rows with dates as YYYY-MM-DD, either date may be left empty
+func readListingFile(filename string) (map[string]Listing, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	listings := make(map[string]Listing)
+	first := true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if first && record[0] == "symbol" {
+			first = false
+			continue
+		}
+		first = false
+
+		var dates [2]int64
+		for i, field := range record[1:] {
+			if field = strings.TrimSpace(field); field == "" {
+				continue
+			}
+			t, err := time.Parse(listingDateLayout, field)
+			if err != nil {
+				return nil, lineError(filename, line, "%q is not a date formatted as %s", field, listingDateLayout)
+			}
+			dates[i] = t.Unix()
+		}
+		if dates[0] != 0 && dates[1] != 0 && dates[1] <= dates[0] {
+			return nil, lineError(filename, line, "%s is delisted before it is listed", record[0])
+		}
+		listings[strings.TrimSpace(record[0])] = Listing{Listed: dates[0], Delisted: dates[1]}
+	}
+
+	return listings, nil
+}
+
+// Listings combines the listing dates known to kiosk with those of the listing file, the file takes precedence
+func (u *Universe) Listings() (map[string]Listing, error) {
+	u.listingLock.Lock()
+	defer u.listingLock.Unlock()
+	if u.listings != nil {
+		return u.listings, nil
+	}
+
+	listings := make(map[string]Listing)
+	info, err := kiosk.GetExchangeInfo()
+	if err != nil {
+		return nil, err
+	}
+	for _, exchange := range info.Exchanges {
+		for symbol, asset := range exchange.Symbols {
+			listings[symbol] = Listing{Listed: asset.OnBoardDate}
+		}
+	}
+	for symbol, listing := range u.ListingFile {
+		listings[symbol] = listing
+	}
+
+	u.listings = listings
+	return listings, nil
+}
+
+// ListedScenarios drops from every scenario the events that occurred while the symbol was not listed or failed the
+// candle filters, unless the universe is not point-in-time. The filters are judged on the candles up to and including
+// the day of the event, the listings and candles are loaded once for all scenarios of the symbol
+func (u *Universe) ListedScenarios(symbol string, scenarios []*algo.ScenarioSet) ([]*algo.ScenarioSet, error) {
+	if !u.PointInTime {
+		return scenarios, nil
+	}
+	listings, err := u.Listings()
+	if err != nil {
+		return nil, err
+	}
+	listing := listings[symbol]
+	var candles *dailyCandles
+	if u.filtered() {
+		if candles, err = loadDailyCandles(symbol); err != nil {
+			return nil, err
+		}
+	}
+
+	// Harvested events are shared by scenarios, so every event is only judged once
+	judged := make(map[int64]bool)
+	listed := func(event *algo.Event) bool {
+		if ok, seen := judged[event.Time]; seen {
+			return ok
+		}
+		ok := listing.ListedAt(event.Time) && (candles == nil || u.tradable(event.Time+candlestick.Interval1d, candles))
+		judged[event.Time] = ok
+		return ok
+	}
+
+	result := make([]*algo.ScenarioSet, 0, len(scenarios))
+	for _, scenario := range scenarios {
+		events := make([]*algo.Event, 0, len(scenario.Events))
+		for _, event := range scenario.Events {
+			if listed(event) {
+				events = append(events, event)
+			}
+		}
+		result = append(result, &algo.ScenarioSet{Events: events, Parameters: scenario.Parameters})
+	}
+	return result, nil
+}
+
+// Survivors keeps the symbols that are still listed today, which is the universe as it was before it became point-in-time
+func (u *Universe) Survivors(symbols []string) ([]string, error) {
+	listings, err := u.Listings()
+	if err != nil {
+		return nil, err
+	}
+	info, err := kiosk.GetExchangeInfo()
+	if err != nil {
+		return nil, err
+	}
+	listedToday := make(map[string]bool)
+	for _, exchange := range info.Exchanges {
+		for symbol := range exchange.Symbols {
+			listedToday[symbol] = true
+		}
+	}
+	result := make([]string, 0)
+	for _, symbol := range symbols {
+		if listedToday[symbol] && listings[symbol].Delisted == 0 {
+			result = append(result, symbol)
+		}
+	}
+	return result, nil
+}
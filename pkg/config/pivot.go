@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dimensions a pivot can break results down by, besides the threshold, the timeout and the algorithm parameters by name
+const (
+	ParamsDimension = "params"
+	SymbolDimension = "symbol"
+	YearDimension   = "year"
+	RegimeDimension = "regime"
+)
+
+// Filter keeps the results of a single value of a dimension
+type Filter struct {
+	Dimension string
+	Value     string
+}
+
+// Pivot is a table of results by a row and a column dimension, keeping the results that pass the filters and
+// aggregating over every other dimension
+type Pivot struct {
+	Name    string
+	Rows    string
+	Columns string
+	Filters []Filter
+}
+
+// DefaultPivots are the threshold by parameters table at a timeout of 14 and the threshold by timeout table
+func DefaultPivots() []Pivot {
+	return []Pivot{
+		{Name: "by-range", Rows: ThresholdDimension, Columns: ParamsDimension, Filters: []Filter{{Dimension: TimeoutDimension, Value: "14"}}},
+		{Name: "by-limit", Rows: ThresholdDimension, Columns: TimeoutDimension},
+	}
+}
+
+// Uses reports whether the pivot breaks down or filters by the dimension
+func (p Pivot) Uses(dimension string) bool {
+	if p.Rows == dimension || p.Columns == dimension {
+		return true
+	}
+	for _, f := range p.Filters {
+		if f.Dimension == dimension {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the pivot the way it is declared
+func (p Pivot) String() string {
+	parts := []string{p.Rows, p.Columns}
+	for _, f := range p.Filters {
+		parts = append(parts, f.Dimension+"="+f.Value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// LoadPivots reads "name = rows columns [dimension=value ...]" lines, the name of a pivot names its tables
+func LoadPivots(filename string) ([]Pivot, error) {
+	entries, err := readEntries(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	pivots := make([]Pivot, 0)
+	for _, e := range entries {
+		if e.Section != "" {
+			return nil, lineError(filename, e.Line, "sections are not supported in pivots")
+		}
+		p, err := parsePivot(e.Key, e.Value)
+		if err != nil {
+			return nil, lineError(filename, e.Line, "%v", err)
+		}
+		pivots = append(pivots, p)
+	}
+	if len(pivots) == 0 {
+		return nil, fmt.Errorf("%s: no pivots declared", filename)
+	}
+	return pivots, nil
+}
+
+func parsePivot(name string, value string) (Pivot, error) {
+	// Output files are split on underscores into the table, evaluator and algorithm
+	if strings.ContainsAny(name, "_/ ") {
+		return Pivot{}, fmt.Errorf("pivot name %q may not contain underscores, slashes or spaces", name)
+	}
+
+	p := Pivot{Name: name}
+	dimensions := make([]string, 0)
+	for _, field := range fields(value) {
+		i := strings.Index(field, "=")
+		if i == -1 {
+			dimensions = append(dimensions, field)
+			continue
+		}
+		f := Filter{Dimension: field[:i], Value: field[i+1:]}
+		if f.Dimension == "" || f.Value == "" {
+			return Pivot{}, fmt.Errorf("malformed filter %q, expected dimension=value", field)
+		}
+		if f.Dimension == ParamsDimension {
+			return Pivot{}, fmt.Errorf("filter on the algorithm parameters by their names instead of %q", ParamsDimension)
+		}
+		if p.Uses(f.Dimension) {
+			return Pivot{}, fmt.Errorf("dimension %q is used more than once", f.Dimension)
+		}
+		p.Filters = append(p.Filters, f)
+	}
+	if len(dimensions) != 2 {
+		return Pivot{}, fmt.Errorf("expected a row and a column dimension, got %q", strings.Join(dimensions, " "))
+	}
+	p.Rows, p.Columns = dimensions[0], dimensions[1]
+	if p.Rows == p.Columns {
+		return Pivot{}, fmt.Errorf("dimension %q is used more than once", p.Rows)
+	}
+	for _, f := range p.Filters {
+		if f.Dimension == p.Rows || f.Dimension == p.Columns {
+			return Pivot{}, fmt.Errorf("dimension %q is used more than once", f.Dimension)
+		}
+	}
+
+	// Periods are split off without their regimes
+	if p.Uses(YearDimension) && p.Uses(RegimeDimension) {
+		return Pivot{}, fmt.Errorf("%q and %q cannot be combined in a pivot", YearDimension, RegimeDimension)
+	}
+	return p, nil
+}
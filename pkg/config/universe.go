@@ -0,0 +1,311 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/godoji/algocore/pkg/kiosk"
+	"github.com/northberg/candlestick"
+	"log"
+	"math"
+	"os"
+	"pattern-evaluator/pkg/db"
+	"pattern-evaluator/pkg/features"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Window in candles over which the average volume is measured
+const volumeWindow = 63
+
+// Universe describes the algorithms and the symbols every command works on
+type Universe struct {
+	Algorithms  []string
+	Exchanges   []string
+	Include     []string
+	Exclude     []string
+	MinPrice    float64
+	MinVolume   float64
+	MinHistory  int
+	PointInTime bool
+	ListingFile map[string]Listing
+	listings    map[string]Listing
+	listingLock sync.Mutex
+}
+
+// readSymbolFile reads one symbol per line, ignoring blank lines and everything after a '#'
+func readSymbolFile(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	symbols := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			symbols = append(symbols, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return symbols, nil
+}
+
+func LoadUniverse(filename string) (*Universe, error) {
+	entries, err := readEntries(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &Universe{}
+	for _, e := range entries {
+		if e.Section != "" {
+			return nil, lineError(filename, e.Line, "sections are not supported in a universe")
+		}
+		switch e.Key {
+		case "algorithms":
+			u.Algorithms = append(u.Algorithms, fields(e.Value)...)
+		case "exchanges":
+			u.Exchanges = append(u.Exchanges, fields(e.Value)...)
+		case "include":
+			u.Include = append(u.Include, fields(e.Value)...)
+		case "exclude":
+			u.Exclude = append(u.Exclude, fields(e.Value)...)
+		case "include-file", "exclude-file":
+			for _, name := range fields(e.Value) {
+				symbols, err := readSymbolFile(name)
+				if err != nil {
+					return nil, lineError(filename, e.Line, "%s: %s", e.Key, err)
+				}
+				if e.Key == "include-file" {
+					u.Include = append(u.Include, symbols...)
+				} else {
+					u.Exclude = append(u.Exclude, symbols...)
+				}
+			}
+		case "min-price", "min-volume":
+			val, err := strconv.ParseFloat(e.Value, 64)
+			if err != nil || val < 0 {
+				return nil, lineError(filename, e.Line, "%s: %q is not a positive number", e.Key, e.Value)
+			}
+			if e.Key == "min-price" {
+				u.MinPrice = val
+			} else {
+				u.MinVolume = val
+			}
+		case "point-in-time":
+			val, err := strconv.ParseBool(e.Value)
+			if err != nil {
+				return nil, lineError(filename, e.Line, "%s: %q is not true or false", e.Key, e.Value)
+			}
+			u.PointInTime = val
+		case "listings-file":
+			listings, err := readListingFile(e.Value)
+			if err != nil {
+				return nil, lineError(filename, e.Line, "%s: %s", e.Key, err)
+			}
+			u.ListingFile = listings
+		case "min-history":
+			val, err := strconv.Atoi(e.Value)
+			if err != nil || val < 0 {
+				return nil, lineError(filename, e.Line, "%s: %q is not a positive number of candles", e.Key, e.Value)
+			}
+			u.MinHistory = val
+		default:
+			return nil, lineError(filename, e.Line, "unknown key %q", e.Key)
+		}
+	}
+
+	if len(u.Algorithms) == 0 {
+		return nil, fmt.Errorf("%s: no algorithms declared", filename)
+	}
+
+	return u, nil
+}
+
+// Symbols lists the symbols of the configured exchanges and the included symbols, without excluded symbols
+// or symbols failing the candle filters. A point-in-time universe also lists the symbols of the listing file.
+func (u *Universe) Symbols() ([]string, error) {
+	excluded := make(map[string]bool)
+	for _, symbol := range u.Exclude {
+		excluded[symbol] = true
+	}
+
+	candidates := make(map[string]bool)
+	if len(u.Exchanges) > 0 {
+		info, err := kiosk.GetExchangeInfo()
+		if err != nil {
+			return nil, err
+		}
+		for _, exchange := range info.Exchanges {
+			for _, id := range u.Exchanges {
+				if exchange.ExchangeId != id {
+					continue
+				}
+				for symbol := range exchange.Symbols {
+					candidates[symbol] = true
+				}
+			}
+		}
+	}
+	for _, symbol := range u.Include {
+		candidates[symbol] = true
+	}
+
+	// Symbols that have since been delisted are missing from the exchange info, but are part of a point-in-time universe.
+	// Kiosk does not know where their candles start, so they are fetched between their listing dates, which leaves out
+	// those without a listing date
+	if u.PointInTime {
+		for symbol, listing := range u.ListingFile {
+			if excluded[symbol] {
+				continue
+			}
+			known, err := KnownToKiosk(symbol)
+			if err != nil {
+				return nil, err
+			}
+			if known {
+				candidates[symbol] = true
+				continue
+			}
+			if listing.Listed == 0 {
+				log.Printf("dropping %s, it is unknown to kiosk and has no listing date to fetch its candles from\n", symbol)
+				continue
+			}
+			to := listing.Delisted
+			if to == 0 {
+				to = time.Now().UTC().Unix()
+			}
+			db.SetSpan(symbol, listing.Listed, to)
+			candidates[symbol] = true
+		}
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, 10)
+	symbolLock := sync.Mutex{}
+	symbols := make([]string, 0)
+	for symbol := range candidates {
+		if excluded[symbol] {
+			continue
+		}
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(s string) {
+			defer wg.Done()
+			if u.accept(s) {
+				symbolLock.Lock()
+				symbols = append(symbols, s)
+				symbolLock.Unlock()
+			}
+			<-semaphore
+		}(symbol)
+	}
+	wg.Wait()
+
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+// KnownToKiosk tells whether a symbol is listed on any exchange of kiosk, only those are served by its algorithms
+func KnownToKiosk(symbol string) (bool, error) {
+	info, err := kiosk.GetExchangeInfo()
+	if err != nil {
+		return false, err
+	}
+	for _, exchange := range info.Exchanges {
+		if _, ok := exchange.Symbol(symbol); ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (u *Universe) filtered() bool {
+	return u.MinPrice != 0 || u.MinVolume != 0 || u.MinHistory != 0
+}
+
+// accept applies the candle filters as of today, only fetching candles when a filter is configured. A point-in-time
+// universe accepts every symbol, as it judges the filters on the day of every event instead
+func (u *Universe) accept(symbol string) bool {
+	if !u.filtered() || u.PointInTime {
+		return true
+	}
+
+	// Delisted symbols are judged by their last days of trading
+	cutoff := time.Now().UTC().Unix()
+	if listing, ok := u.ListingFile[symbol]; ok && listing.Delisted != 0 {
+		cutoff = listing.Delisted
+	}
+	candles, err := loadDailyCandles(symbol)
+	if err != nil {
+		panic(err)
+	}
+	return u.tradable(cutoff, candles)
+}
+
+// dailyCandles are the daily candles of a symbol along with the times of those that are not missing, in order
+type dailyCandles struct {
+	collection []*candlestick.CandleSet
+	times      []int64
+}
+
+func loadDailyCandles(symbol string) (*dailyCandles, error) {
+	collection, err := db.LoadCandles(candlestick.Interval1d, candlestick.Interval1d, symbol)
+	if err != nil {
+		return nil, err
+	}
+	times := make([]int64, 0)
+	for _, set := range collection {
+		for _, c := range set.Candles {
+			if !c.Missing {
+				times = append(times, c.Time)
+			}
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return &dailyCandles{collection: collection, times: times}, nil
+}
+
+// countBefore is the number of candles that are not missing before the cutoff
+func (d *dailyCandles) countBefore(cutoff int64) int {
+	return sort.Search(len(d.times), func(i int) bool { return d.times[i] >= cutoff })
+}
+
+// tradable applies the candle filters to the candles that closed before the cutoff
+func (u *Universe) tradable(cutoff int64, candles *dailyCandles) bool {
+	if u.MinHistory > 0 && candles.countBefore(cutoff) < u.MinHistory {
+		return false
+	}
+	if u.MinPrice == 0 && u.MinVolume == 0 {
+		return true
+	}
+
+	h := features.LoadHistory(cutoff, volumeWindow, candlestick.Interval1d, candles.collection)
+	if len(h.Candles) == 0 {
+		return false
+	}
+	if h.Candles[len(h.Candles)-1].Close < u.MinPrice {
+		return false
+	}
+	if u.MinVolume > 0 {
+		sum := 0.0
+		for _, c := range h.Candles {
+			sum += c.Volume
+		}
+		if math.IsNaN(sum) || sum/float64(len(h.Candles)) < u.MinVolume {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,163 @@
+package crossval
+
+import (
+	"fmt"
+	"pattern-evaluator/pkg/evaluate"
+	"sort"
+)
+
+// Sample is an event along with the stretch of time its label depends on, from the event until the barrier
+// window closes
+type Sample struct {
+	Start int64
+	End   int64
+}
+
+// LabelSpan is the time from an event until its barrier window closes, trading starts a candle after the event
+// and runs for the timeout in candles
+func LabelSpan(timeout int64, interval int64) int64 {
+	return (timeout + 1) * interval
+}
+
+// Fold is a stretch of time of which the events are tested on, from its start up to but excluding its end
+type Fold struct {
+	Index int
+	Start int64
+	End   int64
+}
+
+func (f Fold) String() string {
+	return fmt.Sprintf("fold %d", f.Index+1)
+}
+
+// Split cuts the times of the events into k consecutive folds with about as many events each. The folds cover
+// every time from the first to the last event, so events of other samples fall in a fold as well
+func Split(times []int64, k int) ([]Fold, error) {
+	if k < 2 {
+		return nil, fmt.Errorf("at least two folds are needed, got %d", k)
+	}
+	sorted := append([]int64{}, times...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	if len(sorted) < k {
+		return nil, fmt.Errorf("%d events cannot be split into %d folds", len(sorted), k)
+	}
+
+	folds := make([]Fold, 0, k)
+	for i := 0; i < k; i++ {
+		f := Fold{Index: i, Start: sorted[i*len(sorted)/k]}
+		if i == k-1 {
+			f.End = sorted[len(sorted)-1] + 1
+		} else {
+			f.End = sorted[(i+1)*len(sorted)/k]
+		}
+		if f.Start == f.End {
+			return nil, fmt.Errorf("too many events at the same time to split into %d folds", k)
+		}
+		folds = append(folds, f)
+	}
+	return folds, nil
+}
+
+// Role is the use of a sample in a fold
+type Role int
+
+const (
+	Train Role = iota
+	Test
+	// Purged samples have a label that overlaps the labels of the test fold
+	Purged
+	// Embargoed samples follow the test fold too closely, as their features still see its events
+	Embargoed
+	// Future samples follow the test fold, they are not trained on walking forward
+	Future
+	// Outside samples fall before the first or after the last fold
+	Outside
+)
+
+func (r Role) String() string {
+	switch r {
+	case Train:
+		return "train"
+	case Test:
+		return "test"
+	case Purged:
+		return "purged"
+	case Embargoed:
+		return "embargoed"
+	case Future:
+		return "future"
+	default:
+		return "outside"
+	}
+}
+
+// Scheme decides which samples are trained on for every fold. Walking forward only trains on samples before the
+// test fold, otherwise samples on both sides are
+type Scheme struct {
+	Folds   []Fold
+	Embargo int64
+	Forward bool
+}
+
+// Assign gives the role of every sample in the fold. Training samples are purged when their label overlaps the
+// labels of the test samples, and embargoed when they start within the embargo after the last test label
+func (s Scheme) Assign(fold Fold, samples []Sample) []Role {
+	roles := make([]Role, len(samples))
+
+	// Test labels may run well past the end of the fold
+	labelEnd := fold.End
+	for i, sample := range samples {
+		if sample.Start >= fold.Start && sample.Start < fold.End {
+			roles[i] = Test
+			if sample.End > labelEnd {
+				labelEnd = sample.End
+			}
+		}
+	}
+
+	first, last := s.Folds[0], s.Folds[len(s.Folds)-1]
+	for i, sample := range samples {
+		switch {
+		case roles[i] == Test:
+		case sample.Start < first.Start || sample.Start >= last.End:
+			roles[i] = Outside
+		case sample.Start >= fold.End && s.Forward:
+			roles[i] = Future
+		case sample.Start < labelEnd && sample.End >= fold.Start:
+			roles[i] = Purged
+		case sample.Start >= labelEnd && sample.Start < labelEnd+s.Embargo:
+			roles[i] = Embargoed
+		default:
+			roles[i] = Train
+		}
+	}
+	return roles
+}
+
+// FoldReport holds the metrics of the training and the test samples of a fold, along with the name of what was
+// picked on the training samples, if anything
+type FoldReport struct {
+	Fold      Fold
+	Label     string
+	Train     evaluate.Metrics
+	Test      evaluate.Metrics
+	Purged    int
+	Embargoed int
+}
+
+// Report aggregates the folds, training metrics count a sample once for every fold it trained
+type Report struct {
+	Folds []FoldReport
+	Train evaluate.Metrics
+	Test  evaluate.Metrics
+}
+
+// Aggregate combines the metrics of the folds into a report
+func Aggregate(folds []FoldReport) *Report {
+	r := &Report{Folds: folds}
+	for _, f := range folds {
+		r.Train = evaluate.CombineMetrics(r.Train, f.Train)
+		r.Test = evaluate.CombineMetrics(r.Test, f.Test)
+	}
+	return r
+}
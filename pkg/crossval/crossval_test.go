@@ -0,0 +1,136 @@
+package crossval
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name  string
+		times []int64
+		k     int
+		want  []Fold
+		fails bool
+	}{
+		{
+			name:  "even",
+			times: []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			k:     2,
+			want:  []Fold{{0, 1, 6}, {1, 6, 11}},
+		},
+		{
+			name:  "unsorted",
+			times: []int64{5, 1, 3, 2, 4, 6},
+			k:     3,
+			want:  []Fold{{0, 1, 3}, {1, 3, 5}, {2, 5, 7}},
+		},
+		{
+			name:  "ties at a boundary fall in the later fold",
+			times: []int64{1, 1, 2, 2},
+			k:     2,
+			want:  []Fold{{0, 1, 2}, {1, 2, 3}},
+		},
+		{
+			name:  "the last fold includes the last event",
+			times: []int64{1, 2, 3, 3},
+			k:     2,
+			want:  []Fold{{0, 1, 3}, {1, 3, 4}},
+		},
+		{name: "ties fill a fold", times: []int64{1, 1, 1, 1, 2, 3}, k: 2, fails: true},
+		{name: "a single fold", times: []int64{1, 2, 3}, k: 1, fails: true},
+		{name: "fewer events than folds", times: []int64{1, 2}, k: 3, fails: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Split(tt.times, tt.k)
+			if tt.fails {
+				if err == nil {
+					t.Errorf("Split(%v, %d) = %v, want an error", tt.times, tt.k, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split(%v, %d) = %v, want %v", tt.times, tt.k, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitKeepsTimes(t *testing.T) {
+	times := []int64{3, 1, 2, 4}
+	if _, err := Split(times, 2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(times, []int64{3, 1, 2, 4}) {
+		t.Errorf("Split sorted the times of the caller: %v", times)
+	}
+}
+
+func TestAssign(t *testing.T) {
+	folds := []Fold{{0, 0, 10}, {1, 10, 20}, {2, 20, 30}}
+	samples := []Sample{
+		{0, 5},   // trains, its label ends before the test fold
+		{5, 10},  // its label reaches the start of the test fold
+		{12, 15}, // tests
+		{18, 24}, // tests, with a label past the end of the fold
+		{22, 25}, // starts within the label of the last test sample
+		{24, 27}, // starts within the embargo after the last test label
+		{26, 29}, // trains after the embargo
+		{30, 31}, // after the last fold
+		{-1, 2},  // before the first fold
+	}
+	tests := []struct {
+		name   string
+		scheme Scheme
+		fold   Fold
+		want   []Role
+	}{
+		{
+			name:   "purged and embargoed",
+			scheme: Scheme{Folds: folds, Embargo: 2},
+			fold:   folds[1],
+			want:   []Role{Train, Purged, Test, Test, Purged, Embargoed, Train, Outside, Outside},
+		},
+		{
+			name:   "walking forward",
+			scheme: Scheme{Folds: folds, Embargo: 2, Forward: true},
+			fold:   folds[1],
+			want:   []Role{Train, Purged, Test, Test, Future, Future, Future, Outside, Outside},
+		},
+		{
+			name:   "without an embargo",
+			scheme: Scheme{Folds: folds},
+			fold:   folds[1],
+			want:   []Role{Train, Purged, Test, Test, Purged, Train, Train, Outside, Outside},
+		},
+		{
+			name:   "first fold",
+			scheme: Scheme{Folds: folds, Embargo: 2},
+			fold:   folds[0],
+			want:   []Role{Test, Test, Train, Train, Train, Train, Train, Outside, Outside},
+		},
+		{
+			name:   "last fold",
+			scheme: Scheme{Folds: folds, Embargo: 2},
+			fold:   folds[2],
+			want:   []Role{Train, Train, Train, Purged, Test, Test, Test, Outside, Outside},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.scheme.Assign(tt.fold, samples); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Assign(%s) = %v, want %v", tt.fold, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelSpan(t *testing.T) {
+	if got := LabelSpan(14, 86400); got != 15*86400 {
+		t.Errorf("LabelSpan(14, 86400) = %d, want %d", got, 15*86400)
+	}
+}
@@ -0,0 +1,91 @@
+package cusum
+
+import (
+	"fmt"
+	"github.com/godoji/algocore/pkg/algo"
+	"github.com/northberg/candlestick"
+	"math"
+	"pattern-evaluator/pkg/db"
+	"sort"
+)
+
+// Algorithm is the name under which the filter is harvested, as it runs locally rather than on kiosk
+const Algorithm = "cusum"
+
+// Number of daily returns the volatility is measured over, events only fire once it is known
+const volatilityWindow = 21
+
+// Filter samples the days on which the cumulative log return since the last event in either direction exceeds the
+// multiplier times the volatility of the returns before that day. Candles are expected in order of time
+func Filter(candles []candlestick.Candle, multiplier float64) []*algo.Event {
+	events := make([]*algo.Event, 0)
+	returns := make([]float64, 0, len(candles))
+	up, down := 0.0, 0.0
+	for i := 1; i < len(candles); i++ {
+		previous, current := candles[i-1], candles[i]
+		if previous.Close <= 0 || current.Close <= 0 {
+			continue
+		}
+		r := math.Log(current.Close / previous.Close)
+
+		// The volatility only looks at the returns before the day, so that the day cannot raise its own bar
+		if len(returns) >= volatilityWindow {
+			limit := multiplier * stdDev(returns[len(returns)-volatilityWindow:])
+			up = math.Max(0, up+r)
+			down = math.Min(0, down+r)
+			if limit > 0 && up > limit {
+				events = append(events, newEvent(current, "up"))
+				up = 0
+			} else if limit > 0 && down < -limit {
+				events = append(events, newEvent(current, "down"))
+				down = 0
+			}
+		}
+		returns = append(returns, r)
+	}
+	return events
+}
+
+func newEvent(c candlestick.Candle, label string) *algo.Event {
+	return &algo.Event{
+		CreatedOn: c.Time,
+		Time:      c.Time,
+		Price:     c.Close,
+		Label:     label,
+	}
+}
+
+func stdDev(xs []float64) float64 {
+	mean := 0.0
+	for _, x := range xs {
+		mean += x / float64(len(xs))
+	}
+	variance := 0.0
+	for _, x := range xs {
+		variance += (x - mean) * (x - mean) / float64(len(xs)-1)
+	}
+	return math.Sqrt(variance)
+}
+
+// Harvest samples the daily candles of a symbol with the multiplier that is the first parameter, the events are
+// returned in the form kiosk returns the events of an algorithm
+func Harvest(symbol string, params []float64) (*algo.ScenarioSet, error) {
+	if len(params) < 1 || params[0] <= 0 {
+		return nil, fmt.Errorf("%s takes a positive volatility multiplier as its parameter, got %v", Algorithm, params)
+	}
+
+	candles := make([]candlestick.Candle, 0)
+	for _, set := range db.GetCandles(candlestick.Interval1d, candlestick.Interval1d, symbol) {
+		for _, c := range set.Candles {
+			if !c.Missing {
+				candles = append(candles, c)
+			}
+		}
+	}
+	sort.Slice(candles, func(i, j int) bool { return candles[i].Time < candles[j].Time })
+
+	return &algo.ScenarioSet{
+		Events:     Filter(candles, params[0]),
+		Parameters: params,
+	}, nil
+}
@@ -10,6 +10,29 @@ import (
 
 var cacheLock = sync.Mutex{}
 var cache = make(map[string][]*candlestick.CandleSet)
+var spans = make(map[string][2]int64)
+
+// SetSpan bounds the candles fetched for a symbol, kiosk only knows where the candles of listed symbols start
+func SetSpan(symbol string, from int64, to int64) {
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	spans[symbol] = [2]int64{from, to}
+}
+
+// getCandlesBetween fetches the candle sets covering the span one block at a time
+func getCandlesBetween(interval int64, resolution int64, symbol string, span [2]int64) ([]*candlestick.CandleSet, error) {
+	collection := make([]*candlestick.CandleSet, 0)
+	for i := span[0]; i < span[1]; i += candlestick.CandleSetSize * interval {
+		candles, err := kiosk.GetCandles(candlestick.UnixToBlock(i, interval), interval, resolution, symbol)
+		if err != nil {
+			return nil, err
+		}
+		if candles != nil {
+			collection = append(collection, candles)
+		}
+	}
+	return collection, nil
+}
 
 func GetCandles(interval int64, resolution int64, symbol string) []*candlestick.CandleSet {
 	cacheKey := fmt.Sprintf("%d_%d_%s", interval, resolution, symbol)
@@ -18,7 +41,13 @@ func GetCandles(interval int64, resolution int64, symbol string) []*candlestick.
 	if v, ok := cache[cacheKey]; ok {
 		return v
 	}
-	candles, err := kiosk.GetAllCandles(interval, resolution, symbol)
+	var candles []*candlestick.CandleSet
+	var err error
+	if span, ok := spans[symbol]; ok {
+		candles, err = getCandlesBetween(interval, resolution, symbol, span)
+	} else {
+		candles, err = kiosk.GetAllCandles(interval, resolution, symbol)
+	}
 	if err != nil {
 		panic(err)
 	}
@@ -26,6 +55,16 @@ func GetCandles(interval int64, resolution int64, symbol string) []*candlestick.
 	return candles
 }
 
+// LoadCandles is GetCandles for callers that can do without the candles, it returns the error instead of panicking
+func LoadCandles(interval int64, resolution int64, symbol string) (candles []*candlestick.CandleSet, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("could not load candles of %s: %v", symbol, r)
+		}
+	}()
+	return GetCandles(interval, resolution, symbol), nil
+}
+
 func CandleAtTimestamp(ts int64, collection []*candlestick.CandleSet) *candlestick.Candle {
 	for _, set := range collection {
 		if set == nil {
@@ -42,3 +81,15 @@ func CandleAtTimestamp(ts int64, collection []*candlestick.CandleSet) *candlesti
 	}
 	return nil
 }
+
+// EntryCandle is the candle at which a trade on an event at the timestamp would be entered, the first candle after
+// the event within ten candles, or nil if there is none
+func EntryCandle(ts int64, interval int64, collection []*candlestick.CandleSet) *candlestick.Candle {
+	bookTime := ts + interval
+	for i := int64(0); i < 10; i++ {
+		if c := CandleAtTimestamp(bookTime+i*interval, collection); c != nil {
+			return c
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package evaluate
+
+// AddBreakdown adds the tally of every key in src to the same key in dst
+func AddBreakdown[K comparable, T any](dst map[K]T, src map[K]T, add func(T, T) T) {
+	for key, t := range src {
+		dst[key] = add(dst[key], t)
+	}
+}
+
+// AddCounts sums the counts of every outcome into a new map, either may be nil
+func AddCounts[O comparable](a map[O]int, b map[O]int) map[O]int {
+	sum := make(map[O]int, len(a)+len(b))
+	for o, i := range a {
+		sum[o] += i
+	}
+	for o, i := range b {
+		sum[o] += i
+	}
+	return sum
+}
+
+// CountIn counts an outcome under the key
+func CountIn[K comparable, O comparable](dst map[K]map[O]int, key K, o O) {
+	if _, ok := dst[key]; !ok {
+		dst[key] = make(map[O]int)
+	}
+	dst[key][o]++
+}
+
+// SplitBreakdown turns the tally of every key into metrics of their own
+func SplitBreakdown[K comparable, T any](breakdown map[K]T, metrics func(T) Metrics) map[K]Metrics {
+	split := make(map[K]Metrics, len(breakdown))
+	for key, t := range breakdown {
+		split[key] = metrics(t)
+	}
+	return split
+}
@@ -0,0 +1,358 @@
+package evaluate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AxisKind decides how values are matched against the positions of an axis
+type AxisKind int
+
+const (
+	TextAxis AxisKind = iota
+	NumberAxis
+)
+
+// Axis is a named dimension of a cube, the positions of a numeric axis also carry their value
+type Axis struct {
+	Name   string
+	Kind   AxisKind
+	Labels []string
+	Values []float64
+}
+
+// NewNumberAxis labels every value with the format, such as "thld:%.3f"
+func NewNumberAxis(name string, format string, values []float64) Axis {
+	a := Axis{Name: name, Kind: NumberAxis, Values: values}
+	for _, v := range values {
+		a.Labels = append(a.Labels, fmt.Sprintf(format, v))
+	}
+	return a
+}
+
+func NewTextAxis(name string, labels []string) Axis {
+	return Axis{Name: name, Kind: TextAxis, Labels: labels}
+}
+
+// Index finds the position of a value, which is either a label or a number on a numeric axis
+func (a Axis) Index(value string) (int, bool) {
+	for i, label := range a.Labels {
+		if label == value {
+			return i, true
+		}
+	}
+	if a.Kind != NumberAxis {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	for i, x := range a.Values {
+		if SameParams([]float64{x}, []float64{v}) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// MetricsCube holds the metrics of every combination of the positions of its axes. Cells are stored sparsely by
+// their row-major index, as most combinations of symbols, parameters and periods have no events
+type MetricsCube struct {
+	Axes  []Axis
+	Cells map[int]Metrics
+}
+
+func NewMetricsCube(axes ...Axis) *MetricsCube {
+	return &MetricsCube{
+		Axes:  axes,
+		Cells: make(map[int]Metrics),
+	}
+}
+
+// AxisIndex returns the position of the axis with the name among the axes of the cube
+func (c *MetricsCube) AxisIndex(name string) (int, error) {
+	for i, a := range c.Axes {
+		if a.Name == name {
+			return i, nil
+		}
+	}
+	names := make([]string, len(c.Axes))
+	for i, a := range c.Axes {
+		names[i] = a.Name
+	}
+	return 0, fmt.Errorf("no axis %q, the cube has %s", name, strings.Join(names, ", "))
+}
+
+func (c *MetricsCube) index(coords []int) int {
+	index := 0
+	for i, a := range c.Axes {
+		index = index*len(a.Labels) + coords[i]
+	}
+	return index
+}
+
+func (c *MetricsCube) coords(index int) []int {
+	coords := make([]int, len(c.Axes))
+	for i := len(c.Axes) - 1; i >= 0; i-- {
+		n := len(c.Axes[i].Labels)
+		coords[i] = index % n
+		index /= n
+	}
+	return coords
+}
+
+// Add combines the metrics into the cell at the position on every axis
+func (c *MetricsCube) Add(coords []int, m Metrics) {
+	if m == nil {
+		return
+	}
+	index := c.index(coords)
+	if existing, ok := c.Cells[index]; ok {
+		c.Cells[index] = existing.Combine(m)
+	} else {
+		c.Cells[index] = m
+	}
+}
+
+func (c *MetricsCube) At(coords []int) Metrics {
+	return c.Cells[c.index(coords)]
+}
+
+// Each calls fn with the position on every axis and the metrics of every cell, in no particular order
+func (c *MetricsCube) Each(fn func(coords []int, m Metrics)) {
+	for index, m := range c.Cells {
+		fn(c.coords(index), m)
+	}
+}
+
+// Total combines every cell of the cube, it is nil for an empty cube
+func (c *MetricsCube) Total() Metrics {
+	var total Metrics
+	for _, m := range c.Cells {
+		if total == nil {
+			total = m
+		} else {
+			total = total.Combine(m)
+		}
+	}
+	return total
+}
+
+// project combines the cells that pass keep into a cube of the axes at the positions, in that order
+func (c *MetricsCube) project(axes []int, keep func(coords []int) bool) *MetricsCube {
+	result := NewMetricsCube()
+	for _, i := range axes {
+		result.Axes = append(result.Axes, c.Axes[i])
+	}
+	projected := make([]int, len(axes))
+	for index, m := range c.Cells {
+		coords := c.coords(index)
+		if keep != nil && !keep(coords) {
+			continue
+		}
+		for j, i := range axes {
+			projected[j] = coords[i]
+		}
+		result.Add(projected, m)
+	}
+	return result
+}
+
+// others lists the positions of every axis besides the excluded ones
+func (c *MetricsCube) others(excluded ...int) []int {
+	axes := make([]int, 0, len(c.Axes))
+	for i := range c.Axes {
+		skip := false
+		for _, e := range excluded {
+			skip = skip || i == e
+		}
+		if !skip {
+			axes = append(axes, i)
+		}
+	}
+	return axes
+}
+
+// Slice keeps the cells at a single value of an axis and drops the axis
+func (c *MetricsCube) Slice(name string, value string) (*MetricsCube, error) {
+	axis, err := c.AxisIndex(name)
+	if err != nil {
+		return nil, err
+	}
+	position, ok := c.Axes[axis].Index(value)
+	if !ok {
+		return nil, fmt.Errorf("axis %q has no value %q", name, value)
+	}
+	return c.project(c.others(axis), func(coords []int) bool {
+		return coords[axis] == position
+	}), nil
+}
+
+// Filter keeps the cells at the positions of an axis that pass keep, the value is zero on text axes
+func (c *MetricsCube) Filter(name string, keep func(label string, value float64) bool) (*MetricsCube, error) {
+	axis, err := c.AxisIndex(name)
+	if err != nil {
+		return nil, err
+	}
+	a := c.Axes[axis]
+	kept := make([]bool, len(a.Labels))
+	for i, label := range a.Labels {
+		v := 0.0
+		if a.Kind == NumberAxis {
+			v = a.Values[i]
+		}
+		kept[i] = keep(label, v)
+	}
+	return c.project(c.others(), func(coords []int) bool {
+		return kept[coords[axis]]
+	}), nil
+}
+
+// Marginalise combines the cells over the axes and drops them
+func (c *MetricsCube) Marginalise(names ...string) (*MetricsCube, error) {
+	excluded := make([]int, len(names))
+	for i, name := range names {
+		axis, err := c.AxisIndex(name)
+		if err != nil {
+			return nil, err
+		}
+		excluded[i] = axis
+	}
+	return c.project(c.others(excluded...), nil), nil
+}
+
+// Keep marginalises every axis besides the named ones, which are ordered as given
+func (c *MetricsCube) Keep(names ...string) (*MetricsCube, error) {
+	axes := make([]int, len(names))
+	for i, name := range names {
+		axis, err := c.AxisIndex(name)
+		if err != nil {
+			return nil, err
+		}
+		axes[i] = axis
+	}
+	return c.project(axes, nil), nil
+}
+
+// Flatten replaces the axes by a single text axis of every combination of their positions, labelled by their labels
+// separated by spaces. Without axes to flatten, the new axis has a single position with an empty label
+func (c *MetricsCube) Flatten(names []string, name string) (*MetricsCube, error) {
+	flat := make([]int, len(names))
+	for j, n := range names {
+		axis, err := c.AxisIndex(n)
+		if err != nil {
+			return nil, err
+		}
+		flat[j] = axis
+	}
+	merged := []string{""}
+	for _, i := range flat {
+		next := make([]string, 0, len(merged)*len(c.Axes[i].Labels))
+		for _, prefix := range merged {
+			for _, label := range c.Axes[i].Labels {
+				next = append(next, strings.TrimSpace(prefix+" "+label))
+			}
+		}
+		merged = next
+	}
+	rest := c.others(flat...)
+
+	result := NewMetricsCube()
+	for _, i := range rest {
+		result.Axes = append(result.Axes, c.Axes[i])
+	}
+	result.Axes = append(result.Axes, NewTextAxis(name, merged))
+	coords := make([]int, len(result.Axes))
+	for index, m := range c.Cells {
+		from := c.coords(index)
+		for j, i := range rest {
+			coords[j] = from[i]
+		}
+		position := 0
+		for _, i := range flat {
+			position = position*len(c.Axes[i].Labels) + from[i]
+		}
+		coords[len(rest)] = position
+		result.Add(coords, m)
+	}
+	return result, nil
+}
+
+// Expand splits every cell into parts along a new axis, such as the periods or regimes the metrics were measured in.
+// The labels of the axis are the labels of the parts, in the order of labels when they are given and sorted otherwise
+func (c *MetricsCube) Expand(name string, kind AxisKind, labels []string, split func(m Metrics) map[string]Metrics) *MetricsCube {
+	parts := make(map[int]map[string]Metrics, len(c.Cells))
+	seen := make(map[string]bool)
+	for index, m := range c.Cells {
+		parts[index] = split(m)
+		for label := range parts[index] {
+			seen[label] = true
+		}
+	}
+	if labels == nil {
+		for label := range seen {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+	}
+
+	axis := Axis{Name: name, Kind: kind, Labels: labels}
+	if kind == NumberAxis {
+		axis.Values = make([]float64, len(labels))
+		for i, label := range labels {
+			axis.Values[i], _ = strconv.ParseFloat(label, 64)
+		}
+		sort.Sort(byValue(axis))
+		labels = axis.Labels
+	}
+
+	result := NewMetricsCube(append(append([]Axis{}, c.Axes...), axis)...)
+	position := make(map[string]int, len(labels))
+	for i, label := range labels {
+		position[label] = i
+	}
+	for index, split := range parts {
+		coords := append(c.coords(index), 0)
+		for label, m := range split {
+			p, ok := position[label]
+			if !ok {
+				continue
+			}
+			coords[len(coords)-1] = p
+			result.Add(coords, m)
+		}
+	}
+	return result
+}
+
+type byValue Axis
+
+func (a byValue) Len() int           { return len(a.Labels) }
+func (a byValue) Less(i, j int) bool { return a.Values[i] < a.Values[j] }
+func (a byValue) Swap(i, j int) {
+	a.Labels[i], a.Labels[j] = a.Labels[j], a.Labels[i]
+	a.Values[i], a.Values[j] = a.Values[j], a.Values[i]
+}
+
+// Pivot marginalises every axis besides the row and column axis into a table
+func (c *MetricsCube) Pivot(rows string, columns string) (*MetricsTable, error) {
+	kept, err := c.Keep(rows, columns)
+	if err != nil {
+		return nil, err
+	}
+	table := &MetricsTable{
+		Rows:    kept.Axes[0].Labels,
+		Columns: kept.Axes[1].Labels,
+		Values:  make([][]Metrics, len(kept.Axes[0].Labels)),
+	}
+	for i := range table.Rows {
+		table.Values[i] = make([]Metrics, len(table.Columns))
+		for j := range table.Columns {
+			table.Values[i][j] = kept.At([]int{i, j})
+		}
+	}
+	return table, nil
+}
@@ -0,0 +1,212 @@
+package evaluate
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// count is a metric that adds up, so the cells a projection combined can be told apart by their sum
+type count int
+
+func (c count) Evaluator() string             { return "count" }
+func (c count) String() string                { return fmt.Sprintf("%d", int(c)) }
+func (c count) Value() float64                { return float64(c) }
+func (c count) Size() int                     { return int(c) }
+func (c count) Combine(other Metrics) Metrics { return c + other.(count) }
+func (c count) Emit(key string) float64       { return float64(c) }
+
+// testCube has a distinct bit in every cell, 1 << (4*threshold + 2*limit + symbol)
+func testCube() *MetricsCube {
+	c := NewMetricsCube(
+		NewNumberAxis("thld", "thld:%.3f", []float64{0.01, 0.02}),
+		NewNumberAxis("limit", "limit:%g", []float64{5, 10}),
+		NewTextAxis("symbol", []string{"A", "B"}),
+	)
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 2; j++ {
+			for k := 0; k < 2; k++ {
+				c.Add([]int{i, j, k}, count(1<<(4*i+2*j+k)))
+			}
+		}
+	}
+	return c
+}
+
+func axisNames(c *MetricsCube) []string {
+	names := make([]string, len(c.Axes))
+	for i, a := range c.Axes {
+		names[i] = a.Name
+	}
+	return names
+}
+
+// cells lists the metrics of the cube by position, empty cells are left out
+func cells(c *MetricsCube) map[string]Metrics {
+	result := make(map[string]Metrics)
+	c.Each(func(coords []int, m Metrics) {
+		result[fmt.Sprint(coords)] = m
+	})
+	return result
+}
+
+func TestCubeProjections(t *testing.T) {
+	tests := []struct {
+		name    string
+		project func(c *MetricsCube) (*MetricsCube, error)
+		axes    []string
+		cells   map[string]Metrics
+	}{
+		{
+			name:    "slice by label",
+			project: func(c *MetricsCube) (*MetricsCube, error) { return c.Slice("limit", "limit:10") },
+			axes:    []string{"thld", "symbol"},
+			cells:   map[string]Metrics{"[0 0]": count(4), "[0 1]": count(8), "[1 0]": count(64), "[1 1]": count(128)},
+		},
+		{
+			name:    "slice by number",
+			project: func(c *MetricsCube) (*MetricsCube, error) { return c.Slice("thld", "0.02") },
+			axes:    []string{"limit", "symbol"},
+			cells:   map[string]Metrics{"[0 0]": count(16), "[0 1]": count(32), "[1 0]": count(64), "[1 1]": count(128)},
+		},
+		{
+			name: "filter keeps the axis",
+			project: func(c *MetricsCube) (*MetricsCube, error) {
+				return c.Filter("thld", func(_ string, v float64) bool { return v >= 0.015 })
+			},
+			axes: []string{"thld", "limit", "symbol"},
+			cells: map[string]Metrics{
+				"[1 0 0]": count(16), "[1 0 1]": count(32), "[1 1 0]": count(64), "[1 1 1]": count(128),
+			},
+		},
+		{
+			name:    "marginalise",
+			project: func(c *MetricsCube) (*MetricsCube, error) { return c.Marginalise("symbol") },
+			axes:    []string{"thld", "limit"},
+			cells:   map[string]Metrics{"[0 0]": count(3), "[0 1]": count(12), "[1 0]": count(48), "[1 1]": count(192)},
+		},
+		{
+			name:    "keep reorders the axes",
+			project: func(c *MetricsCube) (*MetricsCube, error) { return c.Keep("symbol", "thld") },
+			axes:    []string{"symbol", "thld"},
+			cells:   map[string]Metrics{"[0 0]": count(5), "[1 0]": count(10), "[0 1]": count(80), "[1 1]": count(160)},
+		},
+		{
+			name:    "flatten",
+			project: func(c *MetricsCube) (*MetricsCube, error) { return c.Flatten([]string{"thld", "limit"}, "params") },
+			axes:    []string{"symbol", "params"},
+			cells: map[string]Metrics{
+				"[0 0]": count(1), "[1 0]": count(2), "[0 1]": count(4), "[1 1]": count(8),
+				"[0 2]": count(16), "[1 2]": count(32), "[0 3]": count(64), "[1 3]": count(128),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testCube()
+			got, err := tt.project(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if names := axisNames(got); !reflect.DeepEqual(names, tt.axes) {
+				t.Errorf("axes = %v, want %v", names, tt.axes)
+			}
+			if m := cells(got); !reflect.DeepEqual(m, tt.cells) {
+				t.Errorf("cells = %v, want %v", m, tt.cells)
+			}
+			if total := c.Total(); total != count(255) {
+				t.Errorf("projecting changed the cube, its total is %v", total)
+			}
+		})
+	}
+}
+
+func TestCubeErrors(t *testing.T) {
+	c := testCube()
+	if _, err := c.Slice("regime", "bull"); err == nil {
+		t.Error("slicing an unknown axis should fail")
+	}
+	if _, err := c.Slice("limit", "7"); err == nil {
+		t.Error("slicing at an unknown value should fail")
+	}
+	if _, err := c.Slice("symbol", "0"); err == nil {
+		t.Error("a text axis should not match numbers")
+	}
+	if _, err := c.Keep("thld", "regime"); err == nil {
+		t.Error("keeping an unknown axis should fail")
+	}
+}
+
+func TestFlattenLabels(t *testing.T) {
+	flat, err := testCube().Flatten([]string{"thld", "limit"}, "params")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"thld:0.010 limit:5", "thld:0.010 limit:10", "thld:0.020 limit:5", "thld:0.020 limit:10"}
+	if labels := flat.Axes[1].Labels; !reflect.DeepEqual(labels, want) {
+		t.Errorf("labels = %q, want %q", labels, want)
+	}
+
+	single, err := testCube().Flatten(nil, "params")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if labels := single.Axes[len(single.Axes)-1].Labels; !reflect.DeepEqual(labels, []string{""}) {
+		t.Errorf("flattening no axes gave labels %q", labels)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	c := NewMetricsCube(NewTextAxis("symbol", []string{"A", "B"}))
+	c.Add([]int{0}, count(3))
+	c.Add([]int{1}, count(4))
+	split := func(m Metrics) map[string]Metrics {
+		return map[string]Metrics{"2021": m, "2020": count(1), "2019": count(100)}
+	}
+
+	// Numeric labels are ordered by value and parts without a label are dropped
+	got := c.Expand("year", NumberAxis, []string{"2021", "2020"}, split)
+	if labels := got.Axes[1].Labels; !reflect.DeepEqual(labels, []string{"2020", "2021"}) {
+		t.Errorf("labels = %q, want [2020 2021]", labels)
+	}
+	want := map[string]Metrics{"[0 0]": count(1), "[0 1]": count(3), "[1 0]": count(1), "[1 1]": count(4)}
+	if m := cells(got); !reflect.DeepEqual(m, want) {
+		t.Errorf("cells = %v, want %v", m, want)
+	}
+
+	// Without labels every part gets a position, sorted by label
+	got = c.Expand("year", TextAxis, nil, split)
+	if labels := got.Axes[1].Labels; !reflect.DeepEqual(labels, []string{"2019", "2020", "2021"}) {
+		t.Errorf("labels = %q, want [2019 2020 2021]", labels)
+	}
+}
+
+func TestPivot(t *testing.T) {
+	table, err := testCube().Pivot("thld", "limit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]Metrics{{count(3), count(12)}, {count(48), count(192)}}
+	if !reflect.DeepEqual(table.Values, want) {
+		t.Errorf("values = %v, want %v", table.Values, want)
+	}
+	if !reflect.DeepEqual(table.Rows, []string{"thld:0.010", "thld:0.020"}) || !reflect.DeepEqual(table.Columns, []string{"limit:5", "limit:10"}) {
+		t.Errorf("rows %q and columns %q do not match the axes", table.Rows, table.Columns)
+	}
+
+	// Cells without events stay empty instead of holding zero metrics
+	sparse := NewMetricsCube(testCube().Axes...)
+	sparse.Add([]int{0, 0, 1}, count(2))
+	sparse.Add([]int{0, 0, 0}, nil)
+	table, err = sparse.Pivot("thld", "limit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = [][]Metrics{{count(2), nil}, {nil, nil}}
+	if !reflect.DeepEqual(table.Values, want) {
+		t.Errorf("values = %v, want %v", table.Values, want)
+	}
+	if total := NewMetricsCube(testCube().Axes...).Total(); total != nil {
+		t.Errorf("the total of an empty cube = %v, want nil", total)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/csv"
 	"fmt"
 	"github.com/godoji/algocore/pkg/algo"
-	"os"
+	"io"
+	"math"
+	"pattern-evaluator/pkg/artifact"
 )
 
 type EvalConfig struct {
@@ -19,6 +21,22 @@ type ParamSet struct {
 	Params    []float64 `json:"params"`
 }
 
+// Parameters are compared relative to their size, as they pass through ranges, formatting and gob encoding
+const paramTolerance = 1e-9
+
+// SameParams reports whether two parameter vectors are equal up to rounding
+func SameParams(a []float64, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > paramTolerance*math.Max(1, math.Max(math.Abs(a[i]), math.Abs(b[i]))) {
+			return false
+		}
+	}
+	return true
+}
+
 type ResultItem struct {
 	Config EvalConfig
 	Result Metrics
@@ -29,6 +47,11 @@ type ResultItemYoY struct {
 	Result map[int]Metrics
 }
 
+// EventID identifies an event of a symbol across algorithms, parameters and exports
+func EventID(symbol string, event *algo.Event) string {
+	return fmt.Sprintf("%s@%d", symbol, event.Time)
+}
+
 type MetricsTable struct {
 	Columns []string
 	Rows    []string
@@ -37,36 +60,47 @@ type MetricsTable struct {
 
 type MetricsGrid = [][]Metrics
 
+// CombineMetrics adds the metrics to the total, either of which may be nil
+func CombineMetrics(total Metrics, m Metrics) Metrics {
+	if total == nil {
+		return m
+	}
+	if m == nil {
+		return total
+	}
+	return total.Combine(m)
+}
+
 func CombineMatrix(dst *MetricsGrid, other MetricsGrid) {
 	for i := range *dst {
 		for j := range (*dst)[i] {
-			if (*dst)[i][j] == nil {
-				(*dst)[i][j] = other[i][j]
-			} else if other[i][j] != nil {
-				(*dst)[i][j] = (*dst)[i][j].Combine(other[i][j])
-			}
+			(*dst)[i][j] = CombineMetrics((*dst)[i][j], other[i][j])
 		}
 	}
 }
 
 func DumpMetrics(g MetricsGrid, key string, filePath string, rowNames []string, colNames []string) {
 
-	file, err := os.Create(filePath)
+	err := artifact.Write(filePath, func(w io.Writer) error {
+		return writeMetrics(w, g, key, rowNames, colNames)
+	})
 	if err != nil {
 		panic(err)
 	}
-	defer file.Close()
+}
+
+func writeMetrics(w io.Writer, g MetricsGrid, key string, rowNames []string, colNames []string) error {
 
 	cols := len(colNames)
 
-	writer := csv.NewWriter(file)
+	writer := csv.NewWriter(w)
 	headerRow := make([]string, cols+1)
 	for i, f := range colNames {
 		headerRow[i+1] = fmt.Sprintf("%s", f)
 	}
-	err = writer.Write(headerRow)
+	err := writer.Write(headerRow)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	for i, row := range g {
 		stringRow := make([]string, cols+1)
@@ -86,11 +120,12 @@ func DumpMetrics(g MetricsGrid, key string, filePath string, rowNames []string,
 		}
 		err := writer.Write(stringRow)
 		if err != nil {
-			panic(err)
+			return err
 		}
 	}
 
 	writer.Flush()
+	return writer.Error()
 }
 
 func Performance(wins int, losses int) float64 {
@@ -131,13 +166,45 @@ func (d DiffMetrics) Emit(key string) float64 {
 	return d.Data.Emit(key) - d.Base.Emit(key)
 }
 
+func indexLabels(labels []string) map[string]int {
+	index := make(map[string]int)
+	for i, label := range labels {
+		index[label] = i
+	}
+	return index
+}
+
+// DiffMetricsTables subtracts the base from every cell with the same row and column label. When the base lacks
+// the column, as random events do not depend on the parameters of an algorithm, the whole row of the base is used.
 func DiffMetricsTables(src *MetricsTable, base *MetricsTable) *MetricsTable {
+	baseRows := indexLabels(base.Rows)
+	baseColumns := indexLabels(base.Columns)
+
 	arr := make([][]Metrics, len(src.Values))
 	for i, value := range src.Values {
 		arr[i] = make([]Metrics, len(value))
+		bi, ok := baseRows[src.Rows[i]]
+		if !ok {
+			continue
+		}
+		var rowBase Metrics
+		for _, m := range base.Values[bi] {
+			if rowBase == nil {
+				rowBase = m
+			} else if m != nil {
+				rowBase = rowBase.Combine(m)
+			}
+		}
 		for j, metrics := range value {
+			b := rowBase
+			if bj, ok := baseColumns[src.Columns[j]]; ok {
+				b = base.Values[bi][bj]
+			}
+			if metrics == nil || b == nil {
+				continue
+			}
 			arr[i][j] = DiffMetrics{
-				Base: base.Values[i][j],
+				Base: b,
 				Data: metrics,
 			}
 		}
@@ -149,6 +216,52 @@ func DiffMetricsTables(src *MetricsTable, base *MetricsTable) *MetricsTable {
 	}
 }
 
+// CompareMetricsTables subtracts every cell of the base from the cell of the source with the same row and column
+// label, the labels that only one of the tables has are left out
+func CompareMetricsTables(src *MetricsTable, base *MetricsTable) *MetricsTable {
+	baseRows := indexLabels(base.Rows)
+	baseColumns := indexLabels(base.Columns)
+
+	rows := make([]int, 0)
+	for i, label := range src.Rows {
+		if _, ok := baseRows[label]; ok {
+			rows = append(rows, i)
+		}
+	}
+	columns := make([]int, 0)
+	for j, label := range src.Columns {
+		if _, ok := baseColumns[label]; ok {
+			columns = append(columns, j)
+		}
+	}
+
+	result := &MetricsTable{
+		Columns: make([]string, len(columns)),
+		Rows:    make([]string, len(rows)),
+		Values:  make([][]Metrics, len(rows)),
+	}
+	for x, j := range columns {
+		result.Columns[x] = src.Columns[j]
+	}
+	for y, i := range rows {
+		result.Rows[y] = src.Rows[i]
+		result.Values[y] = make([]Metrics, len(columns))
+		bi := baseRows[src.Rows[i]]
+		for x, j := range columns {
+			metrics := src.Values[i][j]
+			b := base.Values[bi][baseColumns[src.Columns[j]]]
+			if metrics == nil || b == nil {
+				continue
+			}
+			result.Values[y][x] = DiffMetrics{
+				Base: b,
+				Data: metrics,
+			}
+		}
+	}
+	return result
+}
+
 type Metrics interface {
 	Evaluator() string
 	String() string
@@ -158,6 +271,33 @@ type Metrics interface {
 	Emit(key string) float64
 }
 
+// RegimeMetrics can be split into the part of the metrics that was measured in a single market regime
+type RegimeMetrics interface {
+	ForRegime(regime string) Metrics
+}
+
+// RegimeGrid keeps only the part of every cell that was measured in the given market regime
+func RegimeGrid(g MetricsGrid, regime string) MetricsGrid {
+	arr := make([][]Metrics, len(g))
+	for i, row := range g {
+		arr[i] = make([]Metrics, len(row))
+		for j, metrics := range row {
+			if metrics == nil {
+				continue
+			}
+			if rm, ok := metrics.(RegimeMetrics); ok {
+				arr[i][j] = rm.ForRegime(regime)
+			}
+		}
+	}
+	return arr
+}
+
+// PeriodMetrics can be split into the metrics of every period of a calendar dimension, such as the year
+type PeriodMetrics interface {
+	ByPeriod(dimension string) map[int]Metrics
+}
+
 type Evaluator interface {
 	Evaluate(params *ParamSet, symbol string, events []*algo.Event) Metrics
 }
@@ -0,0 +1,103 @@
+package evaluate
+
+import (
+	"fmt"
+	"math"
+)
+
+// RobustnessMetrics describe how consistent the neighbourhood of a cell is. A cell that does well among neighbours
+// that do not is more likely fitted to noise than to a pattern
+type RobustnessMetrics struct {
+	Cell       Metrics
+	Mean       float64
+	StdDev     float64
+	Neighbours int
+}
+
+func (r RobustnessMetrics) Evaluator() string {
+	return r.Cell.Evaluator()
+}
+
+func (r RobustnessMetrics) String() string {
+	return fmt.Sprintf("%.4f±%.4f/%d", r.Mean, r.StdDev, r.Neighbours)
+}
+
+// Value is the score of the neighbourhood, its mean value less one standard deviation
+func (r RobustnessMetrics) Value() float64 {
+	return r.Mean - r.StdDev
+}
+
+func (r RobustnessMetrics) Size() int {
+	return r.Cell.Size()
+}
+
+func (r RobustnessMetrics) Combine(other Metrics) Metrics {
+	panic("combining robustness metrics is not possible, combine the cells instead")
+}
+
+// Emit returns the score, mean and standard deviation in percent, other keys are emitted by the cell itself
+func (r RobustnessMetrics) Emit(key string) float64 {
+	switch key {
+	case "robustness":
+		return r.Value() * 100
+	case "mean":
+		return r.Mean * 100
+	case "stddev":
+		return r.StdDev * 100
+	default:
+		return r.Cell.Emit(key)
+	}
+}
+
+// Robustness scores every cell of a table by the values of the cells at most the radius of rows and of columns
+// away, including the cell itself, weighted by their number of trades. Rows and columns are taken to be in order, a
+// radius of zero keeps the neighbourhood to the same row or column for labels without an order. Neighbourhoods
+// without trades are left empty
+func Robustness(table *MetricsTable, rowRadius int, columnRadius int) *MetricsTable {
+	arr := make([][]Metrics, len(table.Values))
+	for i, row := range table.Values {
+		arr[i] = make([]Metrics, len(row))
+		for j, m := range row {
+			if m == nil {
+				continue
+			}
+			values, weights := make([]float64, 0), make([]float64, 0)
+			total := 0.0
+			for y := i - rowRadius; y <= i+rowRadius; y++ {
+				for x := j - columnRadius; x <= j+columnRadius; x++ {
+					if y < 0 || y >= len(table.Values) || x < 0 || x >= len(row) || table.Values[y][x] == nil {
+						continue
+					}
+					neighbour := table.Values[y][x]
+					if neighbour.Size() == 0 {
+						continue
+					}
+					values = append(values, neighbour.Value())
+					weights = append(weights, float64(neighbour.Size()))
+					total += float64(neighbour.Size())
+				}
+			}
+			if len(values) == 0 {
+				continue
+			}
+			mean, variance := 0.0, 0.0
+			for k, v := range values {
+				mean += v * weights[k] / total
+			}
+			for k, v := range values {
+				variance += (v - mean) * (v - mean) * weights[k] / total
+			}
+			arr[i][j] = RobustnessMetrics{
+				Cell:       m,
+				Mean:       mean,
+				StdDev:     math.Sqrt(variance),
+				Neighbours: len(values),
+			}
+		}
+	}
+	return &MetricsTable{
+		Columns: table.Columns,
+		Rows:    table.Rows,
+		Values:  arr,
+	}
+}
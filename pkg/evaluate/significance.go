@@ -0,0 +1,129 @@
+package evaluate
+
+import (
+	"math"
+	"sort"
+)
+
+// OutcomeMetrics exposes the wins and losses of which the value of the metrics is the win rate
+type OutcomeMetrics interface {
+	Outcomes() (wins int, losses int)
+}
+
+// BinomialPValue is the two-sided probability of a win count at least as far from half of the trades as the one
+// observed, when wins and losses are equally likely
+func BinomialPValue(wins int, losses int) float64 {
+	n := wins + losses
+	if n == 0 {
+		return 1
+	}
+	k := wins
+	if losses < k {
+		k = losses
+	}
+	lnN, _ := math.Lgamma(float64(n + 1))
+	tail := 0.0
+	for i := 0; i <= k; i++ {
+		lnI, _ := math.Lgamma(float64(i + 1))
+		lnRest, _ := math.Lgamma(float64(n - i + 1))
+		tail += math.Exp(lnN - lnI - lnRest - float64(n)*math.Ln2)
+	}
+	return math.Min(1, 2*tail)
+}
+
+// BinomialUpperPValue is the one-sided probability of at least as many wins as observed, when wins and losses are
+// equally likely
+func BinomialUpperPValue(wins int, losses int) float64 {
+	n := wins + losses
+	if n == 0 {
+		return 1
+	}
+	lnN, _ := math.Lgamma(float64(n + 1))
+	tail := 0.0
+	for i := wins; i <= n; i++ {
+		lnI, _ := math.Lgamma(float64(i + 1))
+		lnRest, _ := math.Lgamma(float64(n - i + 1))
+		tail += math.Exp(lnN - lnI - lnRest - float64(n)*math.Ln2)
+	}
+	return math.Min(1, tail)
+}
+
+// TwoProportionPValue is the one-sided probability of the first win rate exceeding the second by at least as much as
+// observed when both share the pooled win rate, by the normal approximation of the difference
+func TwoProportionPValue(wins int, losses int, baseWins int, baseLosses int) float64 {
+	n, baseN := float64(wins+losses), float64(baseWins+baseLosses)
+	if n == 0 || baseN == 0 {
+		return 1
+	}
+	pooled := float64(wins+baseWins) / (n + baseN)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/n + 1/baseN))
+	diff := float64(wins)/n - float64(baseWins)/baseN
+	if se == 0 {
+		if diff > 0 {
+			return 0
+		}
+		return 1
+	}
+	return 0.5 * math.Erfc(diff/se/math.Sqrt2)
+}
+
+// UpperPValue tests whether the win rate of metrics beats that of the baseline, or a coin flip when there is no
+// baseline with outcomes. Metrics without outcomes are never significant
+func UpperPValue(m Metrics, baseline Metrics) float64 {
+	om, ok := m.(OutcomeMetrics)
+	if !ok {
+		return 1
+	}
+	wins, losses := om.Outcomes()
+	if base, ok := baseline.(OutcomeMetrics); ok {
+		baseWins, baseLosses := base.Outcomes()
+		if baseWins+baseLosses > 0 {
+			return TwoProportionPValue(wins, losses, baseWins, baseLosses)
+		}
+	}
+	return BinomialUpperPValue(wins, losses)
+}
+
+// PValue tests the win rate of metrics against a coin flip, metrics without outcomes are never significant
+func PValue(m Metrics) float64 {
+	om, ok := m.(OutcomeMetrics)
+	if !ok {
+		return 1
+	}
+	return BinomialPValue(om.Outcomes())
+}
+
+// WilsonInterval bounds the win rate at the confidence level, such as 0.95, it is wide open without trades
+func WilsonInterval(wins int, losses int, confidence float64) (float64, float64) {
+	n := float64(wins + losses)
+	if n == 0 {
+		return 0, 1
+	}
+	z := math.Sqrt2 * math.Erfinv(confidence)
+	p := float64(wins) / n
+	center := (p + z*z/(2*n)) / (1 + z*z/n)
+	margin := z / (1 + z*z/n) * math.Sqrt(p*(1-p)/n+z*z/(4*n*n))
+	return math.Max(0, center-margin), math.Min(1, center+margin)
+}
+
+// AdjustPValues controls the false discovery rate over tests that are run together with the Benjamini-Hochberg
+// procedure, the adjusted values are in the order of the p-values
+func AdjustPValues(pValues []float64) []float64 {
+	n := len(pValues)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return pValues[order[i]] < pValues[order[j]]
+	})
+
+	adjusted := make([]float64, n)
+	lowest := 1.0
+	for rank := n; rank >= 1; rank-- {
+		i := order[rank-1]
+		lowest = math.Min(lowest, pValues[i]*float64(n)/float64(rank))
+		adjusted[i] = lowest
+	}
+	return adjusted
+}
@@ -0,0 +1,134 @@
+package evaluate
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a float64, b float64, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+func TestBinomialPValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		wins   int
+		losses int
+		want   float64
+	}{
+		{"no trades", 0, 0, 1},
+		{"even split is capped at one", 5, 5, 1},
+		{"all wins", 10, 0, 2.0 / 1024},
+		{"all losses", 0, 10, 2.0 / 1024},
+		{"eight of ten", 8, 2, 112.0 / 1024},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BinomialPValue(tt.wins, tt.losses); !near(got, tt.want, 1e-12) {
+				t.Errorf("BinomialPValue(%d, %d) = %v, want %v", tt.wins, tt.losses, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBinomialUpperPValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		wins   int
+		losses int
+		want   float64
+	}{
+		{"no trades", 0, 0, 1},
+		{"all wins", 10, 0, 1.0 / 1024},
+		{"all losses", 0, 10, 1},
+		{"even split counts the observed wins", 5, 5, 638.0 / 1024},
+		{"three of four", 3, 1, 5.0 / 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BinomialUpperPValue(tt.wins, tt.losses); !near(got, tt.want, 1e-12) {
+				t.Errorf("BinomialUpperPValue(%d, %d) = %v, want %v", tt.wins, tt.losses, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTwoProportionPValue(t *testing.T) {
+	tests := []struct {
+		name       string
+		wins       int
+		losses     int
+		baseWins   int
+		baseLosses int
+		want       float64
+	}{
+		{"no trades", 0, 0, 5, 5, 1},
+		{"no baseline trades", 5, 5, 0, 0, 1},
+		{"equal rates", 5, 5, 5, 5, 0.5},
+		{"no spread and no difference", 10, 0, 5, 0, 1},
+		{"sixty against fifty percent", 60, 40, 50, 50, 0.0776092448},
+		{"all wins against all losses", 10, 0, 0, 10, 3.8721082e-06},
+		{"worse than the baseline", 40, 60, 50, 50, 1 - 0.0776092448},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TwoProportionPValue(tt.wins, tt.losses, tt.baseWins, tt.baseLosses)
+			if !near(got, tt.want, 1e-9) {
+				t.Errorf("TwoProportionPValue(%d, %d, %d, %d) = %v, want %v", tt.wins, tt.losses, tt.baseWins, tt.baseLosses, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWilsonInterval(t *testing.T) {
+	tests := []struct {
+		name   string
+		wins   int
+		losses int
+		low    float64
+		high   float64
+	}{
+		{"no trades", 0, 0, 0, 1},
+		{"even split", 50, 50, 0.4038315304, 0.5961684696},
+		{"all wins", 10, 0, 0.7224672001, 1},
+		{"all losses", 0, 10, 0, 0.2775327999},
+		{"seven of ten", 7, 3, 0.3967781475, 0.8922087326},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			low, high := WilsonInterval(tt.wins, tt.losses, 0.95)
+			if !near(low, tt.low, 1e-6) || !near(high, tt.high, 1e-6) {
+				t.Errorf("WilsonInterval(%d, %d) = [%v, %v], want [%v, %v]", tt.wins, tt.losses, low, high, tt.low, tt.high)
+			}
+		})
+	}
+}
+
+func TestAdjustPValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		pValues []float64
+		want    []float64
+	}{
+		{"none", []float64{}, []float64{}},
+		{"single", []float64{0.03}, []float64{0.03}},
+		{"kept in input order", []float64{0.01, 0.04, 0.03, 0.005}, []float64{0.02, 0.04, 0.04, 0.02}},
+		{"step-down keeps ranks monotone", []float64{0.04, 0.05}, []float64{0.05, 0.05}},
+		{"ties", []float64{0.02, 0.02}, []float64{0.02, 0.02}},
+		{"never above the largest p-value", []float64{0.9, 0.8}, []float64{0.9, 0.9}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AdjustPValues(tt.pValues)
+			if len(got) != len(tt.want) {
+				t.Fatalf("AdjustPValues(%v) = %v, want %v", tt.pValues, got, tt.want)
+			}
+			for i := range got {
+				if !near(got[i], tt.want[i], 1e-12) {
+					t.Errorf("AdjustPValues(%v) = %v, want %v", tt.pValues, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
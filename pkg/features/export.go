@@ -0,0 +1,145 @@
+package features
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"pattern-evaluator/pkg/artifact"
+	"pattern-evaluator/pkg/crossval"
+	"strconv"
+)
+
+type Row struct {
+	ID     string
+	Symbol string
+	Time   int64
+	Values []float64
+}
+
+func DumpRows(rows []Row, filePath string) {
+
+	err := artifact.Write(filePath, func(w io.Writer) error {
+		return writeRows(w, rows)
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func writeRows(w io.Writer, rows []Row) error {
+
+	writer := csv.NewWriter(w)
+	err := writer.Write(append([]string{"id", "symbol", "time"}, Names()...))
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		stringRow := []string{row.ID, row.Symbol, strconv.FormatInt(row.Time, 10)}
+		for _, v := range row.Values {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				stringRow = append(stringRow, "nan")
+			} else {
+				stringRow = append(stringRow, fmt.Sprintf("%.6f", v))
+			}
+		}
+		err := writer.Write(stringRow)
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// Label is the outcome of a trade on an event, keyed by the event ID like the feature rows
+type Label struct {
+	ID      string
+	Symbol  string
+	Time    int64
+	Outcome string
+	Return  float64
+	Elapsed int64
+}
+
+func DumpLabels(labels []Label, filePath string) {
+
+	err := artifact.Write(filePath, func(w io.Writer) error {
+		return writeLabels(w, labels)
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func writeLabels(w io.Writer, labels []Label) error {
+
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"id", "symbol", "time", "outcome", "return", "elapsed"})
+	if err != nil {
+		return err
+	}
+	for _, label := range labels {
+		stringRow := []string{
+			label.ID,
+			label.Symbol,
+			strconv.FormatInt(label.Time, 10),
+			label.Outcome,
+			fmt.Sprintf("%.6f", label.Return),
+			strconv.FormatInt(label.Elapsed, 10),
+		}
+		err := writer.Write(stringRow)
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// DumpFolds writes the role of every row in every fold of the scheme, so that models fitted to the rows can be
+// cross-validated without labels leaking across folds. Labels span the given time from their event
+func DumpFolds(rows []Row, scheme crossval.Scheme, span int64, filePath string) {
+
+	err := artifact.Write(filePath, func(w io.Writer) error {
+		return writeFolds(w, rows, scheme, span)
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func writeFolds(w io.Writer, rows []Row, scheme crossval.Scheme, span int64) error {
+
+	samples := make([]crossval.Sample, len(rows))
+	for i, row := range rows {
+		samples[i] = crossval.Sample{Start: row.Time, End: row.Time + span}
+	}
+	header := []string{"id"}
+	roles := make([][]crossval.Role, len(scheme.Folds))
+	for i, fold := range scheme.Folds {
+		header = append(header, fmt.Sprintf("fold_%d", fold.Index+1))
+		roles[i] = scheme.Assign(fold, samples)
+	}
+
+	writer := csv.NewWriter(w)
+	err := writer.Write(header)
+	if err != nil {
+		return err
+	}
+	for i, row := range rows {
+		stringRow := []string{row.ID}
+		for f := range scheme.Folds {
+			stringRow = append(stringRow, roles[f][i].String())
+		}
+		err := writer.Write(stringRow)
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
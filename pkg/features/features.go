@@ -0,0 +1,212 @@
+package features
+
+import (
+	"fmt"
+	"github.com/godoji/algocore/pkg/algo"
+	"github.com/northberg/candlestick"
+	"math"
+	"pattern-evaluator/pkg/db"
+)
+
+// Trailing windows in candles, the longest one also bounds the history we need
+var returnWindows = []int{5, 21, 63, 126, 252}
+
+const (
+	volatilityWindow = 21
+	volumeWindow     = 20
+	shortAverage     = 50
+	longAverage      = 200
+	yearWindow       = 252
+	historyLength    = 253
+)
+
+// Names lists the features in the order in which Extract returns them
+func Names() []string {
+	names := make([]string, 0)
+	for _, w := range returnWindows {
+		names = append(names, fmt.Sprintf("ret_%d", w))
+	}
+	return append(names,
+		fmt.Sprintf("vol_%d", volatilityWindow),
+		fmt.Sprintf("dist_ma_%d", shortAverage),
+		fmt.Sprintf("dist_ma_%d", longAverage),
+		fmt.Sprintf("rel_volume_%d", volumeWindow),
+		"gap",
+		"dist_high_52w",
+		"dist_low_52w",
+	)
+}
+
+// History holds the candles known before a point in time, oldest first
+type History struct {
+	Cutoff   int64
+	Interval int64
+	Candles  []*candlestick.Candle
+}
+
+// LoadHistory collects up to n candles that closed strictly before the cutoff, walking back from the cutoff
+func LoadHistory(cutoff int64, n int, interval int64, collection []*candlestick.CandleSet) *History {
+	candles := make([]*candlestick.Candle, 0, n)
+
+	// Allow for weekends and holidays, but stop looking once we passed twice the requested window
+	for i := int64(1); len(candles) < n && i <= int64(2*n); i++ {
+		c := db.CandleAtTimestamp(cutoff-i*interval, collection)
+		if c == nil || c.Close == 0.0 {
+			continue
+		}
+		candles = append(candles, c)
+	}
+
+	// Reverse so that the last candle is the most recent one
+	for i, j := 0, len(candles)-1; i < j; i, j = i+1, j-1 {
+		candles[i], candles[j] = candles[j], candles[i]
+	}
+
+	return &History{Cutoff: cutoff, Interval: interval, Candles: candles}
+}
+
+// CheckLeakage makes sure every candle in the history closed by the cutoff
+func (h *History) CheckLeakage() error {
+	for _, c := range h.Candles {
+		if c.Time+h.Interval > h.Cutoff {
+			return fmt.Errorf("look-ahead leakage: candle at %d closes after cutoff %d", c.Time, h.Cutoff)
+		}
+	}
+	return nil
+}
+
+// Last returns the most recent n candles, or nil if not enough history is available
+func (h *History) Last(n int) []*candlestick.Candle {
+	if n > len(h.Candles) {
+		return nil
+	}
+	return h.Candles[len(h.Candles)-n:]
+}
+
+// Return is the relative price change over the last n candles
+func (h *History) Return(n int) float64 {
+	xs := h.Last(n + 1)
+	if xs == nil {
+		return math.NaN()
+	}
+	return xs[n].Close/xs[0].Close - 1
+}
+
+// MovingAverage is the simple average close of the last n candles
+func (h *History) MovingAverage(n int) float64 {
+	xs := h.Last(n)
+	if xs == nil {
+		return math.NaN()
+	}
+	sum := 0.0
+	for _, c := range xs {
+		sum += c.Close
+	}
+	return sum / float64(n)
+}
+
+// Volatility is the annualised standard deviation of the log returns over the last n candles
+func (h *History) Volatility(n int) float64 {
+	xs := h.Last(n + 1)
+	if xs == nil {
+		return math.NaN()
+	}
+	returns := make([]float64, n)
+	mean := 0.0
+	for i := 1; i < len(xs); i++ {
+		returns[i-1] = math.Log(xs[i].Close / xs[i-1].Close)
+		mean += returns[i-1]
+	}
+	mean /= float64(n)
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(n - 1)
+	return math.Sqrt(variance) * math.Sqrt(yearWindow)
+}
+
+// RelativeVolume compares the volume of the last candle to the average of the n candles before it
+func (h *History) RelativeVolume(n int) float64 {
+	xs := h.Last(n + 1)
+	if xs == nil {
+		return math.NaN()
+	}
+	sum := 0.0
+	for _, c := range xs[:n] {
+		sum += c.Volume
+	}
+	if sum == 0 {
+		return math.NaN()
+	}
+	return xs[n].Volume / (sum / float64(n))
+}
+
+// Gap is the opening jump of the last candle relative to the close of the candle before it
+func (h *History) Gap() float64 {
+	xs := h.Last(2)
+	if xs == nil {
+		return math.NaN()
+	}
+	return xs[1].Open/xs[0].Close - 1
+}
+
+// Range returns the highest high and lowest low of the last n candles
+func (h *History) Range(n int) (float64, float64) {
+	xs := h.Last(n)
+	if xs == nil {
+		return math.NaN(), math.NaN()
+	}
+	high := xs[0].High
+	low := xs[0].Low
+	for _, c := range xs {
+		high = math.Max(high, c.High)
+		low = math.Min(low, c.Low)
+	}
+	return high, low
+}
+
+// EntryTime finds the time of the candle at which a trade on the event would be entered
+func EntryTime(event *algo.Event, interval int64, collection []*candlestick.CandleSet) (int64, bool) {
+	if c := db.EntryCandle(event.Time, interval, collection); c != nil {
+		return c.Time, true
+	}
+	return 0, false
+}
+
+// Extract computes the feature vector of an event from the candles before its entry, features lacking history are NaN
+func Extract(event *algo.Event, interval int64, collection []*candlestick.CandleSet) ([]float64, error) {
+
+	// Everything at or after entry is unknown at the moment we decide to trade
+	cutoff, ok := EntryTime(event, interval, collection)
+	if !ok {
+		return nil, fmt.Errorf("no entry candle after %d", event.Time)
+	}
+
+	h := LoadHistory(cutoff, historyLength, interval, collection)
+	if err := h.CheckLeakage(); err != nil {
+		return nil, err
+	}
+	if len(h.Candles) == 0 {
+		return nil, fmt.Errorf("no history before %d", cutoff)
+	}
+
+	last := h.Candles[len(h.Candles)-1].Close
+	values := make([]float64, 0)
+	for _, w := range returnWindows {
+		values = append(values, h.Return(w))
+	}
+
+	high, low := h.Range(yearWindow)
+	values = append(values,
+		h.Volatility(volatilityWindow),
+		last/h.MovingAverage(shortAverage)-1,
+		last/h.MovingAverage(longAverage)-1,
+		h.RelativeVolume(volumeWindow),
+		h.Gap(),
+		last/high-1,
+		last/low-1,
+	)
+
+	return values, nil
+}
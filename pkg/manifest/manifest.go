@@ -0,0 +1,238 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"pattern-evaluator/pkg/artifact"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Inputs are the hashes or versions of everything an output was built from, by name
+type Inputs map[string]string
+
+type Entry struct {
+	Hash   string `json:"hash"`
+	Inputs Inputs `json:"inputs"`
+	Built  int64  `json:"built"`
+	// Empty outputs were left out by a build that had nothing to write to them
+	Empty bool `json:"empty,omitempty"`
+}
+
+// Manifest records how every output below its directory was built, outputs are keyed by their path relative to that directory
+type Manifest struct {
+	Entries map[string]Entry `json:"entries"`
+	path    string
+	lock    sync.Mutex
+}
+
+// New is an empty manifest stored at the path, under which every output is rebuilt
+func New(path string) *Manifest {
+	return &Manifest{Entries: make(map[string]Entry), path: path}
+}
+
+// Load reads the manifest at the path, a missing manifest is empty
+func Load(path string) (*Manifest, error) {
+	m := New(path)
+	data, err := artifact.Read(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if m.Entries == nil {
+		m.Entries = make(map[string]Entry)
+	}
+	return m, nil
+}
+
+func (m *Manifest) Save() error {
+	m.lock.Lock()
+	data, err := json.MarshalIndent(m, "", "  ")
+	m.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	return artifact.Write(m.path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+func (m *Manifest) key(output string) string {
+	rel, err := filepath.Rel(filepath.Dir(m.path), output)
+	if err != nil {
+		return filepath.ToSlash(output)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Changed lists why the output has to be rebuilt, it is empty when the output is up to date
+func (m *Manifest) Changed(output string, inputs Inputs) []string {
+	m.lock.Lock()
+	entry, ok := m.Entries[m.key(output)]
+	m.lock.Unlock()
+	if _, err := os.Stat(output); err != nil {
+		if !ok || !entry.Empty {
+			return []string{"missing"}
+		}
+	} else if !ok {
+		return []string{"unrecorded"}
+	} else if checksum, err := artifact.Checksum(output); err != nil || checksum != entry.Hash {
+		return []string{"checksum"}
+	}
+	reasons := make([]string, 0)
+	for name, value := range inputs {
+		if entry.Inputs[name] != value {
+			reasons = append(reasons, name)
+		}
+	}
+	for name := range entry.Inputs {
+		if _, ok := inputs[name]; !ok {
+			reasons = append(reasons, name)
+		}
+	}
+	sort.Strings(reasons)
+	return reasons
+}
+
+// Record stores the inputs an output was just built from along with the hash of its content
+func (m *Manifest) Record(output string, inputs Inputs) error {
+	hash, err := contentHash(output)
+	if err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.Entries[m.key(output)] = Entry{Hash: hash, Inputs: inputs, Built: time.Now().UTC().Unix()}
+	return nil
+}
+
+// RecordEmpty stores the inputs of an output that was left out by its build, it is up to date while they are unchanged
+func (m *Manifest) RecordEmpty(output string, inputs Inputs) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.Entries[m.key(output)] = Entry{Inputs: inputs, Built: time.Now().UTC().Unix(), Empty: true}
+}
+
+// Rehash updates the recorded hash of an output whose content was rewritten without changing what it was built from
+func (m *Manifest) Rehash(output string) error {
+	hash, err := contentHash(output)
+	if err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if entry, ok := m.Entries[m.key(output)]; ok {
+		entry.Hash = hash
+		m.Entries[m.key(output)] = entry
+	}
+	return nil
+}
+
+// Rename moves the entry of an output that was replaced by another, which was built from the same inputs
+func (m *Manifest) Rename(from string, to string) error {
+	hash, err := contentHash(to)
+	if err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if entry, ok := m.Entries[m.key(from)]; ok {
+		entry.Hash = hash
+		m.Entries[m.key(to)] = entry
+		delete(m.Entries, m.key(from))
+	}
+	return nil
+}
+
+// Hash returns the content hash of an output, taken from the manifest when it was recorded, empty if the output does not exist
+func (m *Manifest) Hash(output string) string {
+	if _, err := os.Stat(output); err != nil {
+		return ""
+	}
+	m.lock.Lock()
+	entry, ok := m.Entries[m.key(output)]
+	m.lock.Unlock()
+	if ok {
+		return entry.Hash
+	}
+	hash, err := contentHash(output)
+	if err != nil {
+		return ""
+	}
+	return hash
+}
+
+// contentHash prefers the checksum the artifact was written with over hashing it again
+func contentHash(output string) (string, error) {
+	if checksum, err := artifact.Checksum(output); err == nil {
+		return checksum, nil
+	}
+	return HashFile(output)
+}
+
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashValue hashes the JSON encoding of a value, map keys are sorted by the encoder so equal values hash equally
+func HashValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+var codeVersion string
+var codeVersionOnce sync.Once
+
+// CodeVersion identifies the code that builds the outputs, the vcs revision for clean builds and the hash of the executable otherwise
+func CodeVersion() string {
+	codeVersionOnce.Do(func() {
+		revision, modified := "", true
+		if info, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range info.Settings {
+				switch s.Key {
+				case "vcs.revision":
+					revision = s.Value
+				case "vcs.modified":
+					modified = s.Value == "true"
+				}
+			}
+		}
+		if revision != "" && !modified {
+			codeVersion = revision
+			return
+		}
+		codeVersion = "unknown"
+		if exe, err := os.Executable(); err == nil {
+			if hash, err := HashFile(exe); err == nil {
+				codeVersion = "exe:" + hash
+			}
+		}
+	})
+	return codeVersion
+}
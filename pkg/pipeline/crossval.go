@@ -0,0 +1,198 @@
+package pipeline
+
+import (
+	"fmt"
+	"github.com/godoji/algocore/pkg/algo"
+	"github.com/northberg/candlestick"
+	"math"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/crossval"
+	"pattern-evaluator/pkg/evaluate"
+	"pattern-evaluator/pkg/techniques"
+	"strings"
+	"sync"
+)
+
+// CrossValidation picks the configuration with the highest emitted value of the key on the training events of
+// every fold, among those with at least MinSize trades, and tests it on the events of the fold
+type CrossValidation struct {
+	Folds   int
+	Embargo int64
+	Forward bool
+	Key     string
+	MinSize int
+}
+
+// configurationLabel names a combination of the grid the way the axes of a cube label it
+func configurationLabel(hp *config.EvalParams, combo evaluate.ParamSet) string {
+	parts := []string{fmt.Sprintf("thld:%.3f", combo.Threshold), fmt.Sprintf("limit:%d", combo.Timeout)}
+	for i, param := range hp.Params {
+		parts = append(parts, fmt.Sprintf("%s:%g", param.Name, combo.Params[i]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// listedScenarios are the events of every combination of the grid by symbol, in the order of the combinations
+func listedScenarios(algoName string, events Events, combinations []evaluate.ParamSet, opts Options) (map[string][][]*algo.Event, error) {
+	listed := make(map[string][][]*algo.Event)
+	for symbol, scenarios := range events {
+		if opts.Universe != nil {
+			var err error
+			if scenarios, err = opts.Universe.ListedScenarios(symbol, scenarios); err != nil {
+				return nil, err
+			}
+		}
+		listed[symbol] = make([][]*algo.Event, len(combinations))
+		for c, combination := range combinations {
+			if scenario := findScenario(combination, scenarios); scenario != nil {
+				listed[symbol][c] = scenario.Events
+			}
+		}
+	}
+	return listed, nil
+}
+
+// foldResult are the metrics of a combination over the training and test events of a fold
+type foldResult struct {
+	train     evaluate.Metrics
+	test      evaluate.Metrics
+	purged    int
+	embargoed int
+}
+
+func (r *foldResult) add(train evaluate.Metrics, test evaluate.Metrics, purged int, embargoed int) {
+	r.train = evaluate.CombineMetrics(r.train, train)
+	r.test = evaluate.CombineMetrics(r.test, test)
+	r.purged += purged
+	r.embargoed += embargoed
+}
+
+// eventTimes are the times of every distinct event, an event shows up for every combination it was harvested with
+func eventTimes(listed map[string][][]*algo.Event) []int64 {
+	seen := make(map[string]bool)
+	times := make([]int64, 0)
+	for symbol, scenarios := range listed {
+		for _, xs := range scenarios {
+			for _, event := range xs {
+				id := evaluate.EventID(symbol, event)
+				if !seen[id] {
+					seen[id] = true
+					times = append(times, event.Time)
+				}
+			}
+		}
+	}
+	return times
+}
+
+// evaluateFold evaluates every combination on the training and the test events of the fold, events are purged and
+// embargoed by the barrier window of the timeout of the combination. Training events are further limited to those
+// starting from the given time
+func evaluateFold(ev evaluate.Evaluator, listed map[string][][]*algo.Event, combinations []evaluate.ParamSet, scheme crossval.Scheme, fold crossval.Fold, trainFrom int64, opts Options) []foldResult {
+	results := make([]foldResult, len(combinations))
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, opts.workers())
+	resultLock := sync.Mutex{}
+	for symbol, scenarios := range listed {
+		for c, xs := range scenarios {
+			if len(xs) == 0 {
+				continue
+			}
+			wg.Add(1)
+			semaphore <- struct{}{}
+			go func(symbol string, c int, xs []*algo.Event) {
+				defer wg.Done()
+				combo := combinations[c]
+				span := crossval.LabelSpan(combo.Timeout, candlestick.Interval1d)
+				samples := make([]crossval.Sample, len(xs))
+				for i, event := range xs {
+					samples[i] = crossval.Sample{Start: event.Time, End: event.Time + span}
+				}
+
+				train, test := make([]*algo.Event, 0), make([]*algo.Event, 0)
+				purged, embargoed := 0, 0
+				for i, role := range scheme.Assign(fold, samples) {
+					switch role {
+					case crossval.Train:
+						if xs[i].Time >= trainFrom {
+							train = append(train, xs[i])
+						}
+					case crossval.Test:
+						test = append(test, xs[i])
+					case crossval.Purged:
+						purged++
+					case crossval.Embargoed:
+						embargoed++
+					}
+				}
+				var trainMetrics, testMetrics evaluate.Metrics
+				if len(train) > 0 {
+					trainMetrics = ev.Evaluate(&combo, symbol, train)
+				}
+				if len(test) > 0 {
+					testMetrics = ev.Evaluate(&combo, symbol, test)
+				}
+				resultLock.Lock()
+				results[c].add(trainMetrics, testMetrics, purged, embargoed)
+				resultLock.Unlock()
+				<-semaphore
+			}(symbol, c, xs)
+		}
+	}
+	wg.Wait()
+	return results
+}
+
+// pick returns the combination with the highest emitted value of the key on its training events among those with at
+// least minSize trades, larger samples first among equal values. It is -1 when none had enough trades
+func pick(results []foldResult, key string, minSize int) int {
+	picked := -1
+	for c, r := range results {
+		if r.train == nil || r.train.Size() < minSize {
+			continue
+		}
+		if picked == -1 || r.train.Emit(key) > results[picked].train.Emit(key) ||
+			(r.train.Emit(key) == results[picked].train.Emit(key) && r.train.Size() > results[picked].train.Size()) {
+			picked = c
+		}
+	}
+	return picked
+}
+
+// CrossValidate evaluates every combination of the grid on the training and test events of every fold, events
+// are purged and embargoed by the barrier window of the timeout of the combination
+func CrossValidate(algoName string, evaluator string, events Events, grid *config.EvalParams, cv CrossValidation, opts Options) (*crossval.Report, error) {
+
+	ev := techniques.GetHandler(evaluator)
+	if ev == nil {
+		return nil, fmt.Errorf("unknown evaluator: %s", evaluator)
+	}
+	hp := grid.ForAlgorithm(algoName)
+	combinations := hp.Combinations()
+	listed, err := listedScenarios(algoName, events, combinations, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Folds are cut over every distinct event
+	folds, err := crossval.Split(eventTimes(listed), cv.Folds)
+	if err != nil {
+		return nil, err
+	}
+	scheme := crossval.Scheme{Folds: folds, Embargo: cv.Embargo, Forward: cv.Forward}
+
+	reports := make([]crossval.FoldReport, 0, len(folds))
+	for _, fold := range folds {
+		results := evaluateFold(ev, listed, combinations, scheme, fold, math.MinInt64, opts)
+		report := crossval.FoldReport{Fold: fold}
+		if picked := pick(results, cv.Key, cv.MinSize); picked != -1 {
+			r := results[picked]
+			report.Label = configurationLabel(hp, combinations[picked])
+			report.Train, report.Test = r.train, r.test
+			report.Purged, report.Embargoed = r.purged, r.embargoed
+		}
+		opts.logf("[%s, %s] %s: picked %q\n", algoName, evaluator, fold, report.Label)
+		reports = append(reports, report)
+	}
+	return crossval.Aggregate(reports), nil
+}
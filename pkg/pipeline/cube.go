@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/evaluate"
+	"sort"
+)
+
+// Cube arranges the results of an algorithm along the threshold, the timeout, every algorithm parameter and the
+// symbol, results that are not on the grid are left out
+func Cube(algoName string, results Results, grid *config.EvalParams) *evaluate.MetricsCube {
+
+	hp := grid.ForAlgorithm(algoName)
+
+	timeouts := make([]float64, len(hp.TimeLimits))
+	for i, v := range hp.TimeLimits {
+		timeouts[i] = float64(v)
+	}
+	symbols := make([]string, 0, len(results))
+	for symbol := range results {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	axes := []evaluate.Axis{
+		evaluate.NewNumberAxis(config.ThresholdDimension, "thld:%.3f", hp.Thresholds),
+		evaluate.NewNumberAxis(config.TimeoutDimension, "limit:%.0f", timeouts),
+	}
+	for _, param := range hp.Params {
+		axes = append(axes, evaluate.NewNumberAxis(param.Name, param.Name+":%g", param.Values))
+	}
+	axes = append(axes, evaluate.NewTextAxis(config.SymbolDimension, symbols))
+	cube := evaluate.NewMetricsCube(axes...)
+
+	for s, symbol := range symbols {
+	nextResult:
+		for _, item := range results[symbol] {
+			options := item.Config.Options
+			if len(options.Params) != len(hp.Params) {
+				continue
+			}
+			coords := make([]int, len(axes))
+			coords[len(coords)-1] = s
+			values := append([]float64{options.Threshold, float64(options.Timeout)}, options.Params...)
+			for i, v := range values {
+				position, ok := indexOf(axes[i].Values, v)
+				if !ok {
+					continue nextResult
+				}
+				coords[i] = position
+			}
+			cube.Add(coords, item.Result)
+		}
+	}
+	return cube
+}
+
+func indexOf(values []float64, v float64) (int, bool) {
+	for i, x := range values {
+		if evaluate.SameParams([]float64{x}, []float64{v}) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// ParamAxes names the axes of a cube that are algorithm parameters
+func ParamAxes(cube *evaluate.MetricsCube) []string {
+	names := make([]string, 0)
+	for _, a := range cube.Axes {
+		switch a.Name {
+		case config.ThresholdDimension, config.TimeoutDimension, config.SymbolDimension, config.YearDimension, config.RegimeDimension:
+		default:
+			names = append(names, a.Name)
+		}
+	}
+	return names
+}
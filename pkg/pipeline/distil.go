@@ -0,0 +1,141 @@
+package pipeline
+
+import (
+	"fmt"
+	"pattern-evaluator/pkg/calendar"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/evaluate"
+	"pattern-evaluator/pkg/regime"
+	"strconv"
+)
+
+// byRegime reports whether a pivot is also built for every market regime, which is not the case when it already
+// uses the regime or breaks results down by period, as periods are split off without their regimes
+func byRegime(pivot config.Pivot) bool {
+	return !pivot.Uses(config.RegimeDimension) && !pivot.Uses(config.YearDimension)
+}
+
+// TableNames lists the names of the tables returned by Distil for the pivots
+func TableNames(pivots []config.Pivot) []string {
+	names := make([]string, 0)
+	for _, pivot := range pivots {
+		names = append(names, pivot.Name)
+		if !byRegime(pivot) {
+			continue
+		}
+		for _, r := range regime.Labels() {
+			names = append(names, pivot.Name+"-"+r)
+		}
+	}
+	return names
+}
+
+// splits are the dimensions that split the metrics of a cell into parts, rather than being an axis of the cube
+var splits = map[string]func(c *evaluate.MetricsCube) *evaluate.MetricsCube{
+	config.YearDimension: func(c *evaluate.MetricsCube) *evaluate.MetricsCube {
+		return c.Expand(config.YearDimension, evaluate.NumberAxis, nil, func(m evaluate.Metrics) map[string]evaluate.Metrics {
+			parts := make(map[string]evaluate.Metrics)
+			if pm, ok := m.(evaluate.PeriodMetrics); ok {
+				for year, part := range pm.ByPeriod(calendar.Year) {
+					parts[strconv.Itoa(year)] = part
+				}
+			}
+			return parts
+		})
+	},
+	config.RegimeDimension: func(c *evaluate.MetricsCube) *evaluate.MetricsCube {
+		labels := regime.Labels()
+		return c.Expand(config.RegimeDimension, evaluate.TextAxis, labels, func(m evaluate.Metrics) map[string]evaluate.Metrics {
+			parts := make(map[string]evaluate.Metrics)
+			if rm, ok := m.(evaluate.RegimeMetrics); ok {
+				for _, r := range labels {
+					parts[r] = rm.ForRegime(r)
+				}
+			}
+			return parts
+		})
+	},
+}
+
+// query keeps the cells of the cube that pass the filters of the pivot, split by the year or regime when it uses them
+func query(pivot config.Pivot, cube *evaluate.MetricsCube) (*evaluate.MetricsCube, error) {
+	var err error
+	c := cube
+
+	// Filters on the axes of the cube go first, so that fewer cells are split
+	for _, f := range pivot.Filters {
+		if _, ok := splits[f.Dimension]; ok {
+			continue
+		}
+		if c, err = c.Slice(f.Dimension, f.Value); err != nil {
+			return nil, err
+		}
+	}
+	for dimension, split := range splits {
+		if !pivot.Uses(dimension) {
+			continue
+		}
+		c = split(c)
+		for _, f := range pivot.Filters {
+			if f.Dimension != dimension {
+				continue
+			}
+			if c, err = c.Slice(f.Dimension, f.Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// Pivot queries the table of a pivot from the cube of an algorithm
+func Pivot(pivot config.Pivot, cube *evaluate.MetricsCube) (*evaluate.MetricsTable, error) {
+	c, err := query(pivot, cube)
+	if err != nil {
+		return nil, err
+	}
+	if pivot.Rows == config.ParamsDimension || pivot.Columns == config.ParamsDimension {
+		if c, err = c.Flatten(ParamAxes(c), config.ParamsDimension); err != nil {
+			return nil, err
+		}
+	}
+	return c.Pivot(pivot.Rows, pivot.Columns)
+}
+
+// Distil queries the cube of an algorithm for the table of every pivot, along with one table for every market
+// regime the events were tagged with, such as "by-range-bull-calm", unless the pivot uses the regime or the year
+func Distil(algoName string, cube *evaluate.MetricsCube, opts Options) (map[string]*evaluate.MetricsTable, error) {
+
+	tables := make(map[string]*evaluate.MetricsTable)
+	if len(cube.Cells) == 0 {
+		return tables, nil
+	}
+	for _, pivot := range opts.pivots() {
+		table, err := Pivot(pivot, cube)
+		if err != nil {
+			return nil, fmt.Errorf("pivot %s: %w", pivot.Name, err)
+		}
+		if len(table.Rows) == 0 || len(table.Columns) == 0 {
+			opts.logf("%s: pivot %s has no results, skipped\n", algoName, pivot.Name)
+			continue
+		}
+		if byRegime(pivot) {
+			addTables(tables, pivot.Name, table)
+		} else {
+			tables[pivot.Name] = table
+		}
+	}
+	return tables, nil
+}
+
+func addTables(tables map[string]*evaluate.MetricsTable, prefix string, table *evaluate.MetricsTable) {
+	tables[prefix] = table
+	for _, r := range regime.Labels() {
+		tables[prefix+"-"+r] = &evaluate.MetricsTable{
+			Columns: table.Columns,
+			Rows:    table.Rows,
+			Values:  evaluate.RegimeGrid(table.Values, r),
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package pipeline
+
+import (
+	"github.com/godoji/algocore/pkg/algo"
+	"github.com/godoji/algocore/pkg/kiosk"
+	"github.com/northberg/candlestick"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/cusum"
+	"sync"
+)
+
+// Events are the harvested scenarios of an algorithm by symbol, one scenario per parameter vector
+type Events = map[string][]*algo.ScenarioSet
+
+// HarvestScenario fetches the events of an algorithm for a single parameter vector, the CUSUM filter is sampled
+// locally
+func HarvestScenario(algoName string, symbol string, params []float64) (*algo.ScenarioSet, error) {
+	if algoName == cusum.Algorithm {
+		return cusum.Harvest(symbol, params)
+	}
+	if algoName == "random" {
+		res, err := kiosk.GetAlgorithm("random", candlestick.Interval1d, symbol, []float64{0.01}, true)
+		if err != nil {
+			return nil, err
+		}
+		res.Parameters = params
+		return res, nil
+	}
+	return kiosk.GetAlgorithm(algoName, candlestick.Interval1d, symbol, params, true)
+}
+
+// HarvestSymbol fetches the events of every parameter vector of the algorithm for a symbol. Kiosk cannot run its
+// algorithms on delisted symbols it no longer knows, these have no events but those sampled locally
+func HarvestSymbol(algoName string, symbol string, grid *config.EvalParams, opts Options) ([]*algo.ScenarioSet, error) {
+
+	if algoName != cusum.Algorithm {
+		known, err := config.KnownToKiosk(symbol)
+		if err != nil {
+			return nil, err
+		}
+		if !known {
+			opts.logf("[%s -> %s] skipped, the symbol is unknown to kiosk\n", algoName, symbol)
+			return []*algo.ScenarioSet{}, nil
+		}
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, opts.workers())
+	resultLock := sync.Mutex{}
+	results := make([]*algo.ScenarioSet, 0)
+	var firstErr error
+
+	for _, vector := range grid.ForAlgorithm(algoName).ParamVectors() {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(p []float64) {
+			defer wg.Done()
+			events, err := HarvestScenario(algoName, symbol, p)
+			resultLock.Lock()
+			if err != nil && firstErr == nil {
+				firstErr = err
+			} else if err == nil {
+				results = append(results, events)
+			}
+			resultLock.Unlock()
+			<-semaphore
+		}(vector)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
+
+// Harvest fetches the events of an algorithm for every symbol
+func Harvest(algoName string, symbols []string, grid *config.EvalParams, opts Options) (Events, error) {
+	events := make(Events)
+	for _, symbol := range symbols {
+		scenarios, err := HarvestSymbol(algoName, symbol, grid, opts)
+		if err != nil {
+			return nil, err
+		}
+		events[symbol] = scenarios
+		opts.logf("[%s -> %s] harvested %d scenarios\n", algoName, symbol, len(scenarios))
+	}
+	return events, nil
+}
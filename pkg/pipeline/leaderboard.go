@@ -0,0 +1,113 @@
+package pipeline
+
+import (
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/evaluate"
+	"sort"
+)
+
+// Standing is the place of a symbol on a leaderboard, the interval bounds the win rate in percent
+type Standing struct {
+	Symbol string
+	Size   int
+	Value  float64
+	Lower  float64
+	Upper  float64
+}
+
+// Ranking is the leaderboard of the symbols at a single position on every other axis of a cube
+type Ranking struct {
+	Labels    []string
+	Standings []Standing
+}
+
+func newStanding(symbol string, m evaluate.Metrics, key string, confidence float64) Standing {
+	s := Standing{Symbol: symbol, Size: m.Size(), Value: m.Emit(key), Lower: 0, Upper: 100}
+	if om, ok := m.(evaluate.OutcomeMetrics); ok {
+		wins, losses := om.Outcomes()
+		lower, upper := evaluate.WilsonInterval(wins, losses, confidence)
+		s.Lower, s.Upper = lower*100, upper*100
+	}
+	return s
+}
+
+// sortStandings ranks the highest value first, larger samples first among equal values
+func sortStandings(standings []Standing) {
+	sort.Slice(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if a.Value != b.Value {
+			return a.Value > b.Value
+		}
+		if a.Size != b.Size {
+			return a.Size > b.Size
+		}
+		return a.Symbol < b.Symbol
+	})
+}
+
+// Leaderboards ranks the symbols of a cube by the emitted value of the key at every position on its other axes, in
+// the order of those axes. Symbols with less than minSize trades are left out
+func Leaderboards(cube *evaluate.MetricsCube, key string, minSize int, confidence float64) ([]Ranking, error) {
+	symbolAxis, err := cube.AxisIndex(config.SymbolDimension)
+	if err != nil {
+		return nil, err
+	}
+
+	rankings := make(map[int]*Ranking)
+	cube.Each(func(coords []int, m evaluate.Metrics) {
+		if m.Size() < minSize {
+			return
+		}
+		group := 0
+		labels := make([]string, 0, len(coords)-1)
+		for i, a := range cube.Axes {
+			if i == symbolAxis {
+				continue
+			}
+			group = group*len(a.Labels) + coords[i]
+			labels = append(labels, a.Labels[coords[i]])
+		}
+		r, ok := rankings[group]
+		if !ok {
+			r = &Ranking{Labels: labels}
+			rankings[group] = r
+		}
+		symbol := cube.Axes[symbolAxis].Labels[coords[symbolAxis]]
+		r.Standings = append(r.Standings, newStanding(symbol, m, key, confidence))
+	})
+
+	groups := make([]int, 0, len(rankings))
+	for group := range rankings {
+		groups = append(groups, group)
+	}
+	sort.Ints(groups)
+	result := make([]Ranking, 0, len(groups))
+	for _, group := range groups {
+		sortStandings(rankings[group].Standings)
+		result = append(result, *rankings[group])
+	}
+	return result, nil
+}
+
+// RankSymbols ranks the symbols by the emitted value of the key over everything the pivot keeps
+func RankSymbols(pivot config.Pivot, cube *evaluate.MetricsCube, key string, minSize int, confidence float64) ([]Standing, error) {
+	c, err := query(pivot, cube)
+	if err != nil {
+		return nil, err
+	}
+	if c, err = c.Keep(config.SymbolDimension); err != nil {
+		return nil, err
+	}
+	rankings, err := Leaderboards(c, key, minSize, confidence)
+	if err != nil || len(rankings) == 0 {
+		return nil, err
+	}
+	return rankings[0].Standings, nil
+}
+
+// SymbolPivot queries the table of a pivot for a single symbol
+func SymbolPivot(pivot config.Pivot, cube *evaluate.MetricsCube, symbol string) (*evaluate.MetricsTable, error) {
+	p := pivot
+	p.Filters = append(append([]config.Filter{}, pivot.Filters...), config.Filter{Dimension: config.SymbolDimension, Value: symbol})
+	return Pivot(p, cube)
+}
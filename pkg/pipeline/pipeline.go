@@ -0,0 +1,57 @@
+package pipeline
+
+import (
+	"fmt"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/evaluate"
+)
+
+const defaultWorkers = 10
+
+// Options tune the stages of the pipeline, the zero value runs every stage with its defaults
+type Options struct {
+	// Workers limits the number of concurrent requests and evaluations
+	Workers int
+	// Universe drops the events of unlisted symbols while processing when it is point-in-time
+	Universe *config.Universe
+	// Pivots are the tables distil builds, the by-range and by-limit tables when there are none
+	Pivots []config.Pivot
+	// Logf receives progress messages, nothing is logged when it is nil
+	Logf func(format string, args ...interface{})
+}
+
+func (o Options) workers() int {
+	if o.Workers <= 0 {
+		return defaultWorkers
+	}
+	return o.Workers
+}
+
+func (o Options) pivots() []config.Pivot {
+	if len(o.Pivots) == 0 {
+		return config.DefaultPivots()
+	}
+	return o.Pivots
+}
+
+func (o Options) logf(format string, args ...interface{}) {
+	if o.Logf != nil {
+		o.Logf(format, args...)
+	}
+}
+
+// Evaluate runs an algorithm through harvest, process and distil in memory and returns the tables by name
+func Evaluate(algoName string, evaluator string, symbols []string, grid *config.EvalParams, opts Options) (map[string]*evaluate.MetricsTable, error) {
+	events, err := Harvest(algoName, symbols, grid, opts)
+	if err != nil {
+		return nil, err
+	}
+	results, err := Process(algoName, evaluator, events, grid, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%s has no results for %s", algoName, evaluator)
+	}
+	return Distil(algoName, Cube(algoName, results, grid), opts)
+}
@@ -0,0 +1,109 @@
+package pipeline
+
+import (
+	"fmt"
+	"github.com/godoji/algocore/pkg/algo"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/evaluate"
+	"pattern-evaluator/pkg/techniques"
+	"sync"
+)
+
+// Results are the evaluated metrics of an algorithm by symbol, one item per parameter combination
+type Results = map[string][]evaluate.ResultItem
+
+func findScenario(params evaluate.ParamSet, scenarios []*algo.ScenarioSet) *algo.ScenarioSet {
+	for _, scenario := range scenarios {
+		if evaluate.SameParams(scenario.Parameters, params.Params) {
+			return scenario
+		}
+	}
+	return nil
+}
+
+// evaluateSymbol queues an evaluation of the scenarios of a symbol for every combination of the grid, the evaluations
+// take their turn on the semaphore and hand their results to add under the lock. The scenarios are filtered by the
+// universe once, as the combinations share them
+func evaluateSymbol(ev evaluate.Evaluator, algoName string, evaluator string, symbol string, scenarios []*algo.ScenarioSet, grid *config.EvalParams, opts Options, wg *sync.WaitGroup, semaphore chan struct{}, resultLock *sync.Mutex, add func(evaluate.ResultItem)) error {
+	if opts.Universe != nil {
+		var err error
+		if scenarios, err = opts.Universe.ListedScenarios(symbol, scenarios); err != nil {
+			return err
+		}
+	}
+	for _, combination := range grid.ForAlgorithm(algoName).Combinations() {
+		scenario := findScenario(combination, scenarios)
+		if scenario == nil {
+			opts.logf("[%s, %s] no events harvested for %s with %v\n", algoName, evaluator, symbol, combination.Params)
+			continue
+		}
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(combo evaluate.ParamSet, events []*algo.Event) {
+			defer wg.Done()
+			metrics := ev.Evaluate(&combo, symbol, events)
+			resultLock.Lock()
+			add(evaluate.ResultItem{
+				Config: evaluate.EvalConfig{
+					Name:    algoName,
+					Symbol:  symbol,
+					Options: combo,
+				},
+				Result: metrics,
+			})
+			resultLock.Unlock()
+			<-semaphore
+		}(combination, scenario.Events)
+	}
+	return nil
+}
+
+// ProcessSymbol evaluates the scenarios of a symbol for every combination of the grid
+func ProcessSymbol(algoName string, evaluator string, symbol string, scenarios []*algo.ScenarioSet, grid *config.EvalParams, opts Options) ([]evaluate.ResultItem, error) {
+
+	ev := techniques.GetHandler(evaluator)
+	if ev == nil {
+		return nil, fmt.Errorf("unknown evaluator: %s", evaluator)
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, opts.workers())
+	resultLock := sync.Mutex{}
+	results := make([]evaluate.ResultItem, 0)
+	err := evaluateSymbol(ev, algoName, evaluator, symbol, scenarios, grid, opts, &wg, semaphore, &resultLock, func(item evaluate.ResultItem) {
+		results = append(results, item)
+	})
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Process evaluates the harvested events of every symbol, symbols without results are left out. The evaluations of
+// all symbols share the workers
+func Process(algoName string, evaluator string, events Events, grid *config.EvalParams, opts Options) (Results, error) {
+
+	ev := techniques.GetHandler(evaluator)
+	if ev == nil {
+		return nil, fmt.Errorf("unknown evaluator: %s", evaluator)
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, opts.workers())
+	resultLock := sync.Mutex{}
+	output := make(Results)
+	for symbol, scenarios := range events {
+		err := evaluateSymbol(ev, algoName, evaluator, symbol, scenarios, grid, opts, &wg, semaphore, &resultLock, func(item evaluate.ResultItem) {
+			output[item.Config.Symbol] = append(output[item.Config.Symbol], item)
+		})
+		if err != nil {
+			wg.Wait()
+			return nil, err
+		}
+	}
+	wg.Wait()
+
+	return output, nil
+}
@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"pattern-evaluator/pkg/evaluate"
+	"sort"
+)
+
+// Configuration is a cell of a table, the results of an algorithm and evaluator at the settings of its row and column
+type Configuration struct {
+	Table     string
+	Evaluator string
+	Algorithm string
+	Row       string
+	Column    string
+	Size      int
+	Value     float64
+	// VsRandom is the value minus the value of random events in the same cell, when there are random events
+	VsRandom  float64
+	HasRandom bool
+	PValue    float64
+	QValue    float64
+}
+
+// Configurations lists the cells of a table with at least minSize trades, valued by the emitted value of the key.
+// As the best values rank first, the p-value tests whether the win rate beats the random events in the same cell, or
+// a coin flip without them. The random table may be nil
+func Configurations(table string, evaluator string, algoName string, t *evaluate.MetricsTable, random *evaluate.MetricsTable, key string, minSize int) []Configuration {
+	var diff *evaluate.MetricsTable
+	if random != nil {
+		diff = evaluate.DiffMetricsTables(t, random)
+	}
+
+	configs := make([]Configuration, 0)
+	for i, row := range t.Values {
+		for j, m := range row {
+			if m == nil || m.Size() < minSize {
+				continue
+			}
+			c := Configuration{
+				Table:     table,
+				Evaluator: evaluator,
+				Algorithm: algoName,
+				Row:       t.Rows[i],
+				Column:    t.Columns[j],
+				Size:      m.Size(),
+				Value:     m.Emit(key),
+			}
+			var baseline evaluate.Metrics
+			if diff != nil && diff.Values[i][j] != nil {
+				c.VsRandom = diff.Values[i][j].Emit(key)
+				c.HasRandom = true
+				baseline = diff.Values[i][j].(evaluate.DiffMetrics).Base
+			}
+			c.PValue = evaluate.UpperPValue(m, baseline)
+			configs = append(configs, c)
+		}
+	}
+	return configs
+}
+
+// RankConfigurations adjusts the p-values of the configurations for being tested together and ranks the highest
+// value first, larger samples first among equal values
+func RankConfigurations(configs []Configuration) {
+	pValues := make([]float64, len(configs))
+	for i, c := range configs {
+		pValues[i] = c.PValue
+	}
+	for i, q := range evaluate.AdjustPValues(pValues) {
+		configs[i].QValue = q
+	}
+
+	sort.SliceStable(configs, func(i, j int) bool {
+		a, b := configs[i], configs[j]
+		if a.Value != b.Value {
+			return a.Value > b.Value
+		}
+		return a.Size > b.Size
+	})
+}
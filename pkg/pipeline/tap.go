@@ -0,0 +1,54 @@
+package pipeline
+
+import (
+	"image"
+	"pattern-evaluator/pkg/chart"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/evaluate"
+	"strings"
+)
+
+// ordered reports whether the labels of a dimension have an order, neighbouring symbols or regimes are unrelated.
+// Flattened parameters are only in order for a single parameter, with more the neighbours differ in any of them
+func ordered(dimension string, labels []string) bool {
+	switch dimension {
+	case config.SymbolDimension, config.RegimeDimension:
+		return false
+	case config.ParamsDimension:
+		for _, label := range labels {
+			if strings.Contains(label, " ") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Robustness scores the cells of a table of the pivot by their neighbours within the radius along its ordered
+// dimensions
+func Robustness(pivot config.Pivot, table *evaluate.MetricsTable, radius int) *evaluate.MetricsTable {
+	rowRadius, columnRadius := 0, 0
+	if ordered(pivot.Rows, table.Rows) {
+		rowRadius = radius
+	}
+	if ordered(pivot.Columns, table.Columns) {
+		columnRadius = radius
+	}
+	return evaluate.Robustness(table, rowRadius, columnRadius)
+}
+
+// Tap renders the heatmaps of a table by kind, the diff against random is left out when there is no random table
+// and the robustness is left out without a radius
+func Tap(title string, pivot config.Pivot, table *evaluate.MetricsTable, random *evaluate.MetricsTable, radius int) map[string]*image.RGBA {
+	images := make(map[string]*image.RGBA)
+	if random != nil {
+		images["diff"] = chart.Heatmap(title, evaluate.DiffMetricsTables(table, random), "balanced")
+	}
+	for _, key := range []string{"balanced", "worst", "size"} {
+		images[key] = chart.Heatmap(title, table, key)
+	}
+	if radius > 0 {
+		images["robustness"] = chart.Heatmap(title, Robustness(pivot, table, radius), "robustness")
+	}
+	return images
+}
@@ -0,0 +1,97 @@
+package pipeline
+
+import (
+	"fmt"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/crossval"
+	"pattern-evaluator/pkg/evaluate"
+	"pattern-evaluator/pkg/techniques"
+	"time"
+)
+
+// Window is a step of a walk-forward evaluation, the configuration that did best over the training years is tested
+// on the year that follows them. The configuration is empty when none had enough trades to be picked
+type Window struct {
+	TrainFrom     int
+	TrainTo       int
+	Test          int
+	Configuration string
+	Purged        int
+	InSample      evaluate.Metrics
+	OutOfSample   evaluate.Metrics
+}
+
+// WalkForwardResult stitches the windows together, the in-sample metrics count a year once for every window it
+// trained
+type WalkForwardResult struct {
+	Windows     []Window
+	InSample    evaluate.Metrics
+	OutOfSample evaluate.Metrics
+}
+
+func yearStart(year int) int64 {
+	return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+}
+
+// WalkForward picks the combination of the grid that did best by the emitted value of the key over every stretch of
+// train years, and tests it on the year after. Combinations are only picked with at least minSize trades in the
+// training years. Training events whose barrier window runs into the test year are purged, as their outcome is only
+// known once the test year has started
+func WalkForward(algoName string, evaluator string, events Events, grid *config.EvalParams, key string, train int, minSize int, opts Options) (*WalkForwardResult, error) {
+	if train < 1 {
+		return nil, fmt.Errorf("at least one training year is needed, got %d", train)
+	}
+	ev := techniques.GetHandler(evaluator)
+	if ev == nil {
+		return nil, fmt.Errorf("unknown evaluator: %s", evaluator)
+	}
+	hp := grid.ForAlgorithm(algoName)
+	combinations := hp.Combinations()
+	listed, err := listedScenarios(algoName, events, combinations, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &WalkForwardResult{}
+	times := eventTimes(listed)
+	if len(times) == 0 {
+		return result, nil
+	}
+	first := time.Unix(times[0], 0).UTC().Year()
+	last := first
+	for _, t := range times {
+		year := time.Unix(t, 0).UTC().Year()
+		if year < first {
+			first = year
+		}
+		if year > last {
+			last = year
+		}
+	}
+
+	// Every year is a fold, walking forward only trains on the years before the test year
+	folds := make([]crossval.Fold, 0, last-first+1)
+	for year := first; year <= last; year++ {
+		folds = append(folds, crossval.Fold{Index: year - first, Start: yearStart(year), End: yearStart(year + 1)})
+	}
+	scheme := crossval.Scheme{Folds: folds, Forward: true}
+	if len(folds) <= train {
+		return result, nil
+	}
+
+	for _, fold := range folds[train:] {
+		w := Window{TrainFrom: first + fold.Index - train, TrainTo: first + fold.Index - 1, Test: first + fold.Index}
+		results := evaluateFold(ev, listed, combinations, scheme, fold, yearStart(w.TrainFrom), opts)
+		if picked := pick(results, key, minSize); picked != -1 {
+			r := results[picked]
+			w.Configuration = configurationLabel(hp, combinations[picked])
+			w.Purged = r.purged
+			w.InSample, w.OutOfSample = r.train, r.test
+		}
+		opts.logf("[%s, %s] %d: picked %q\n", algoName, evaluator, w.Test, w.Configuration)
+		result.InSample = evaluate.CombineMetrics(result.InSample, w.InSample)
+		result.OutOfSample = evaluate.CombineMetrics(result.OutOfSample, w.OutOfSample)
+		result.Windows = append(result.Windows, w)
+	}
+	return result, nil
+}
@@ -0,0 +1,129 @@
+package regime
+
+import (
+	"github.com/northberg/candlestick"
+	"log"
+	"math"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/db"
+	"pattern-evaluator/pkg/features"
+	"sync"
+)
+
+const (
+	Unknown = "unknown"
+
+	trendAverage    = 200
+	shortVolatility = 21
+	longVolatility  = 252
+	historyLength   = 253
+	bullLabel       = "bull"
+	bearLabel       = "bear"
+	calmLabel       = "calm"
+	volatileLabel   = "volatile"
+	labelSeparator  = "-"
+)
+
+// Labels lists every regime an event can be tagged with, apart from Unknown
+func Labels() []string {
+	labels := make([]string, 0)
+	for _, trend := range []string{bullLabel, bearLabel} {
+		for _, vol := range []string{calmLabel, volatileLabel} {
+			labels = append(labels, trend+labelSeparator+vol)
+		}
+	}
+	return labels
+}
+
+// Classifier tags points in time by the trend and volatility of a benchmark symbol
+type Classifier struct {
+	symbol     string
+	interval   int64
+	loadOnce   sync.Once
+	collection []*candlestick.CandleSet
+	cacheLock  sync.Mutex
+	cache      map[int64]string
+}
+
+func NewClassifier(symbol string, interval int64) *Classifier {
+	return &Classifier{
+		symbol:   symbol,
+		interval: interval,
+		cache:    make(map[int64]string),
+	}
+}
+
+// candles loads the benchmark candles on first use, without them every point in time is of an unknown regime
+func (c *Classifier) candles() []*candlestick.CandleSet {
+	c.loadOnce.Do(func() {
+		collection, err := db.LoadCandles(c.interval, candlestick.Interval1d, c.symbol)
+		if err != nil {
+			log.Printf("regimes are unknown: %v\n", err)
+			return
+		}
+		c.collection = collection
+	})
+	return c.collection
+}
+
+// Classify determines the regime using only the benchmark candles that closed before the cutoff
+func (c *Classifier) Classify(cutoff int64) string {
+	c.cacheLock.Lock()
+	if v, ok := c.cache[cutoff]; ok {
+		c.cacheLock.Unlock()
+		return v
+	}
+	c.cacheLock.Unlock()
+
+	collection := c.candles()
+	if len(collection) == 0 {
+		return Unknown
+	}
+	h := features.LoadHistory(cutoff, historyLength, c.interval, collection)
+	result := classify(h)
+
+	c.cacheLock.Lock()
+	c.cache[cutoff] = result
+	c.cacheLock.Unlock()
+
+	return result
+}
+
+func classify(h *features.History) string {
+	if len(h.Candles) == 0 {
+		return Unknown
+	}
+
+	// Above the long moving average is a bull market, below a bear market
+	average := h.MovingAverage(trendAverage)
+	shortVol := h.Volatility(shortVolatility)
+	longVol := h.Volatility(longVolatility)
+	if math.IsNaN(average) || math.IsNaN(shortVol) || math.IsNaN(longVol) {
+		return Unknown
+	}
+	trend := bearLabel
+	if h.Candles[len(h.Candles)-1].Close >= average {
+		trend = bullLabel
+	}
+
+	// Recent volatility above its yearly level serves as a proxy for a high VIX
+	vol := calmLabel
+	if shortVol > longVol {
+		vol = volatileLabel
+	}
+
+	return trend + labelSeparator + vol
+}
+
+var defaultClassifier *Classifier
+var defaultLock sync.Mutex
+
+// Default returns the classifier on the configured benchmark symbol for daily candles
+func Default() *Classifier {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	if defaultClassifier == nil {
+		defaultClassifier = NewClassifier(config.GetBenchmarkSymbol(), candlestick.Interval1d)
+	}
+	return defaultClassifier
+}
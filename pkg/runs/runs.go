@@ -0,0 +1,252 @@
+package runs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"pattern-evaluator/pkg/artifact"
+	"sort"
+	"time"
+)
+
+const (
+	metadataFile = "run.json"
+	// Latest is the name of the link to the most recently created run, and the reference that resolves to it
+	Latest   = "latest"
+	idLayout = "20060102-150405"
+)
+
+// Inputs are snapshots of the configuration files a command read, empty for the files it did not read
+type Inputs struct {
+	Params   string `json:"params,omitempty"`
+	Universe string `json:"universe,omitempty"`
+	Pivots   string `json:"pivots,omitempty"`
+}
+
+// Stage is the last time a command ran against the run, along with the configuration it ran with
+type Stage struct {
+	Args     []string `json:"args"`
+	Started  int64    `json:"started"`
+	Finished int64    `json:"finished"`
+	Millis   int64    `json:"millis"`
+	Inputs
+}
+
+// Run is the metadata of a run directory, describing what its outputs were built from
+type Run struct {
+	ID       string            `json:"id"`
+	Created  int64             `json:"created"`
+	Tags     []string          `json:"tags"`
+	Revision string            `json:"revision"`
+	Settings map[string]string `json:"settings"`
+	Stages   map[string]Stage  `json:"stages"`
+	Dir      string            `json:"-"`
+}
+
+// Create starts a new run below the root and makes it the latest run
+func Create(root string) (*Run, error) {
+	err := os.MkdirAll(root, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	id := now.Format(idLayout)
+	for i := 2; ; i++ {
+		err = os.Mkdir(filepath.Join(root, id), 0755)
+		if !os.IsExist(err) {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", now.Format(idLayout), i)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Run{
+		ID:       id,
+		Created:  now.Unix(),
+		Tags:     make([]string, 0),
+		Settings: make(map[string]string),
+		Stages:   make(map[string]Stage),
+		Dir:      filepath.Join(root, id),
+	}
+	if err = r.Save(); err != nil {
+		return nil, err
+	}
+	return r, SetLatest(root, id)
+}
+
+func load(root string, id string) (*Run, error) {
+	dir := filepath.Join(root, id)
+	data, err := artifact.Read(filepath.Join(dir, metadataFile))
+	if err != nil {
+		return nil, err
+	}
+	r := &Run{}
+	if err = json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Join(dir, metadataFile), err)
+	}
+	if r.Settings == nil {
+		r.Settings = make(map[string]string)
+	}
+	if r.Stages == nil {
+		r.Stages = make(map[string]Stage)
+	}
+	r.Dir = dir
+	return r, nil
+}
+
+// Open resolves a reference to a run, which is either "latest", the id of a run or one of its tags
+func Open(root string, ref string) (*Run, error) {
+	if ref == Latest {
+		id, err := LatestID(root)
+		if err != nil {
+			return nil, err
+		}
+		return load(root, id)
+	}
+	if _, err := os.Stat(filepath.Join(root, ref, metadataFile)); err == nil {
+		return load(root, ref)
+	}
+	all, err := List(root)
+	if err != nil {
+		return nil, err
+	}
+	var found *Run
+	for _, r := range all {
+		if r.HasTag(ref) {
+			if found != nil {
+				return nil, fmt.Errorf("tag %s is used by both run %s and run %s", ref, found.ID, r.ID)
+			}
+			found = r
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no run with id or tag %s in %s", ref, root)
+	}
+	return found, nil
+}
+
+// List returns every run below the root, oldest first
+func List(root string) ([]*Run, error) {
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Run, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Type()&os.ModeSymlink != 0 {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(root, entry.Name(), metadataFile)); err != nil {
+			continue
+		}
+		r, err := load(root, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Created != result[j].Created {
+			return result[i].Created < result[j].Created
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func LatestID(root string) (string, error) {
+	target, err := os.Readlink(filepath.Join(root, Latest))
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("there are no runs in %s yet", root)
+	}
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(target), nil
+}
+
+// SetLatest points the latest link at a run, replacing the link atomically
+func SetLatest(root string, id string) error {
+	tmp := filepath.Join(root, "."+Latest+".tmp")
+	_ = os.Remove(tmp)
+	if err := os.Symlink(id, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(root, Latest))
+}
+
+// Delete removes a run, the latest link moves to the most recent run that is left
+func Delete(root string, id string) error {
+	latest, _ := LatestID(root)
+	if err := os.RemoveAll(filepath.Join(root, id)); err != nil {
+		return err
+	}
+	if latest != id {
+		return nil
+	}
+	left, err := List(root)
+	if err != nil {
+		return err
+	}
+	if len(left) == 0 {
+		return os.Remove(filepath.Join(root, Latest))
+	}
+	return SetLatest(root, left[len(left)-1].ID)
+}
+
+func (r *Run) Save() error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return artifact.Write(filepath.Join(r.Dir, metadataFile), func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+func (r *Run) HasTag(tag string) bool {
+	for _, t := range r.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Run) Tag(tag string) {
+	if !r.HasTag(tag) {
+		r.Tags = append(r.Tags, tag)
+		sort.Strings(r.Tags)
+	}
+}
+
+func (r *Run) Untag(tag string) {
+	tags := make([]string, 0, len(r.Tags))
+	for _, t := range r.Tags {
+		if t != tag {
+			tags = append(tags, t)
+		}
+	}
+	r.Tags = tags
+}
+
+// Record stores the arguments and timing of a command that ran against the run
+func (r *Run) Record(stage string, args []string, started time.Time, inputs Inputs) {
+	finished := time.Now().UTC()
+	r.Stages[stage] = Stage{
+		Args:     args,
+		Started:  started.Unix(),
+		Finished: finished.Unix(),
+		Millis:   finished.Sub(started).Milliseconds(),
+		Inputs:   inputs,
+	}
+}
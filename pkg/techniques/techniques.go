@@ -1,14 +1,21 @@
 package techniques
 
 import (
+	"pattern-evaluator/pkg/benchmark"
 	"pattern-evaluator/pkg/bucket"
 	"pattern-evaluator/pkg/evaluate"
+	"pattern-evaluator/pkg/trendscan"
 	"pattern-evaluator/pkg/triplebarrier"
 )
 
 var handlerMapping = map[string]evaluate.Evaluator{
-	"barriers": &triplebarrier.Evaluator{},
-	"buckets":  &bucket.Evaluator{},
+	"barriers":        &triplebarrier.Evaluator{},
+	"buckets":         &bucket.Evaluator{},
+	"barriers-market": &triplebarrier.Evaluator{Returns: benchmark.MarketAdjusted},
+	"buckets-market":  &bucket.Evaluator{Returns: benchmark.MarketAdjusted},
+	"barriers-beta":   &triplebarrier.Evaluator{Returns: benchmark.BetaAdjusted},
+	"buckets-beta":    &bucket.Evaluator{Returns: benchmark.BetaAdjusted},
+	"trends":          &trendscan.Evaluator{},
 }
 
 func GetHandler(name string) evaluate.Evaluator {
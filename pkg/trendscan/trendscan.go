@@ -0,0 +1,297 @@
+package trendscan
+
+import (
+	"fmt"
+	"github.com/godoji/algocore/pkg/algo"
+	"github.com/northberg/candlestick"
+	"math"
+	"pattern-evaluator/pkg/benchmark"
+	"pattern-evaluator/pkg/calendar"
+	"pattern-evaluator/pkg/config"
+	"pattern-evaluator/pkg/db"
+	"pattern-evaluator/pkg/evaluate"
+	"pattern-evaluator/pkg/regime"
+)
+
+const (
+	// Shortest horizon in candles a trend is fitted over, unless the timeout is shorter
+	minHorizon = 5
+	// A perfect fit has an infinite t-value, which would swamp the average
+	maxTValue = 100
+	// Events whose most significant trend has a smaller absolute t-value are flat, about a two-sided 5% significance
+	flatTValue = 2
+)
+
+type Label int
+
+const (
+	Down Label = iota
+	Flat
+	Up
+)
+
+// Tally counts the labels of events along with the sum of the t-values they were labelled by
+type Tally struct {
+	Up   int
+	Down int
+	Flat int
+	SumT float64
+}
+
+func (t Tally) add(other Tally) Tally {
+	return Tally{Up: t.Up + other.Up, Down: t.Down + other.Down, Flat: t.Flat + other.Flat, SumT: t.SumT + other.SumT}
+}
+
+func (t *Tally) count(label Label, tValue float64) {
+	switch label {
+	case Up:
+		t.Up++
+	case Down:
+		t.Down++
+	default:
+		t.Flat++
+	}
+	t.SumT += tValue
+}
+
+type TrendMetrics struct {
+	Tally
+	Undefined  int
+	ByYear     map[int]Tally
+	ByQuarter  map[int]Tally
+	ByMonth    map[int]Tally
+	ByWeekday  map[int]Tally
+	ByEarnings map[int]Tally
+	ByRegime   map[string]Tally
+}
+
+func newTrendMetrics() *TrendMetrics {
+	return &TrendMetrics{
+		ByYear:     make(map[int]Tally),
+		ByQuarter:  make(map[int]Tally),
+		ByMonth:    make(map[int]Tally),
+		ByWeekday:  make(map[int]Tally),
+		ByEarnings: make(map[int]Tally),
+		ByRegime:   make(map[string]Tally),
+	}
+}
+
+type Evaluator struct {
+	Returns benchmark.ReturnModel
+}
+
+// Evaluate labels the events over horizons up to the timeout, the labels do not depend on the threshold
+func (e *Evaluator) Evaluate(params *evaluate.ParamSet, symbol string, events []*algo.Event) evaluate.Metrics {
+	return Evaluate(symbol, candlestick.Interval1d, events, params.Timeout, e.Returns)
+}
+
+func (tm TrendMetrics) Combine(other evaluate.Metrics) evaluate.Metrics {
+	var otherMetrics TrendMetrics
+	if m, ok := other.(*TrendMetrics); ok {
+		otherMetrics = *m
+	} else if m, ok := other.(TrendMetrics); ok {
+		otherMetrics = m
+	} else {
+		panic("cannot not add other type than TrendMetrics")
+	}
+
+	combined := newTrendMetrics()
+	combined.Tally = tm.Tally.add(otherMetrics.Tally)
+	combined.Undefined = tm.Undefined + otherMetrics.Undefined
+	for _, m := range []TrendMetrics{tm, otherMetrics} {
+		for _, dimension := range calendar.Dimensions() {
+			evaluate.AddBreakdown(combined.breakdown(dimension), m.breakdown(dimension), Tally.add)
+		}
+		evaluate.AddBreakdown(combined.ByRegime, m.ByRegime, Tally.add)
+	}
+	return *combined
+}
+
+// countIn adds a label to the tally of the key
+func countIn[K comparable](dst map[K]Tally, key K, label Label, tValue float64) {
+	t := dst[key]
+	t.count(label, tValue)
+	dst[key] = t
+}
+
+// fromTally holds the tally of a single period or regime
+func fromTally(t Tally) evaluate.Metrics {
+	m := newTrendMetrics()
+	m.Tally = t
+	return *m
+}
+
+func (tm TrendMetrics) ForRegime(r string) evaluate.Metrics {
+	m := fromTally(tm.ByRegime[r]).(TrendMetrics)
+	m.ByRegime[r] = m.Tally
+	return m
+}
+
+func (tm TrendMetrics) breakdown(dimension string) map[int]Tally {
+	return calendar.Pick(dimension, tm.ByYear, tm.ByQuarter, tm.ByMonth, tm.ByWeekday, tm.ByEarnings)
+}
+
+// ByPeriod splits the metrics into the metrics of every period of a calendar dimension
+func (tm TrendMetrics) ByPeriod(dimension string) map[int]evaluate.Metrics {
+	return evaluate.SplitBreakdown(tm.breakdown(dimension), fromTally)
+}
+
+func (tm TrendMetrics) Evaluator() string {
+	return "Trend Scanning"
+}
+
+// Size counts the events that were labelled up or down, like trades that hit a barrier
+func (tm TrendMetrics) Size() int {
+	return tm.Up + tm.Down
+}
+
+func (tm TrendMetrics) String() string {
+	return fmt.Sprintf("%d/%d+%d", tm.Up, tm.Down, tm.Flat)
+}
+
+func (tm TrendMetrics) Outcomes() (int, int) {
+	return tm.Up, tm.Down
+}
+
+func (tm TrendMetrics) Value() float64 {
+	return evaluate.Performance(tm.Up, tm.Down)
+}
+
+// Emit adds the average t-value of the labels and the balance of up over down labels among all labels in percent
+func (tm TrendMetrics) Emit(key string) float64 {
+	total := tm.Up + tm.Down + tm.Flat
+	switch key {
+	case "worst":
+		return evaluate.Performance(tm.Up, tm.Down+tm.Flat) * 100
+	case "balanced":
+		return evaluate.Performance(tm.Up, tm.Down) * 100
+	case "size":
+		return float64(tm.Size())
+	case "wins":
+		return float64(tm.Up)
+	case "tvalue":
+		if total == 0 {
+			return 0
+		}
+		return tm.SumT / float64(total)
+	case "balance":
+		if total == 0 {
+			return 0
+		}
+		return float64(tm.Up-tm.Down) / float64(total) * 100
+	default:
+		panic("undefined emit key")
+	}
+}
+
+// tValue fits a line through the values and returns the t-value of its slope
+func tValue(ys []float64) float64 {
+	n := float64(len(ys))
+	meanX, meanY := (n-1)/2, 0.0
+	for _, y := range ys {
+		meanY += y / n
+	}
+	sxx, sxy := 0.0, 0.0
+	for i, y := range ys {
+		dx := float64(i) - meanX
+		sxx += dx * dx
+		sxy += dx * (y - meanY)
+	}
+	slope := sxy / sxx
+	sse := 0.0
+	for i, y := range ys {
+		residual := y - meanY - slope*(float64(i)-meanX)
+		sse += residual * residual
+	}
+	se := math.Sqrt(sse / (n - 2) / sxx)
+	if se == 0 {
+		// A flat series has no trend at all, rather than a perfect one
+		if slope == 0 {
+			return 0
+		}
+		return math.Copysign(maxTValue, slope)
+	}
+	return math.Max(-maxTValue, math.Min(maxTValue, slope/se))
+}
+
+// findTrend fits a trend through the excess returns from entry over every horizon up to the timeout, and labels the
+// event by the sign of the t-value of the fit with the largest absolute t-value. Events without a significant trend
+// over any horizon are flat
+func findTrend(event *algo.Event, timeout int64, interval int64, collection []*candlestick.CandleSet, model benchmark.ReturnModel) (Label, float64, int64, bool) {
+
+	// Find the candle at the start time, or after the start time if no candle was available
+	startCandle := db.EntryCandle(event.Time, interval, collection)
+	if startCandle == nil || startCandle.Open == 0.0 {
+		return Flat, 0, 0, false
+	}
+
+	// The entry price of our trade would be at the opening of the start candle
+	entryPrice := startCandle.Open
+	startTime := startCandle.Time
+
+	// The part of the return we expect from the benchmark alone, zero when measuring raw returns
+	path, ok := benchmark.NewPath(model, startTime, interval, collection)
+	if !ok {
+		return Flat, 0, 0, false
+	}
+
+	// Missing candles are left out of the fit rather than filled in
+	returns := make([]float64, 0, timeout)
+	for i := int64(0); i < timeout; i++ {
+		currentCandle := db.CandleAtTimestamp(startTime+i*interval, collection)
+		if currentCandle == nil {
+			continue
+		}
+		returns = append(returns, (currentCandle.Close-entryPrice)/entryPrice-path.Return(currentCandle.Time))
+	}
+
+	shortest := minHorizon
+	if int(timeout) < shortest {
+		shortest = int(timeout)
+	}
+	if shortest < 3 {
+		shortest = 3
+	}
+	if len(returns) < shortest {
+		return Flat, 0, startTime, false
+	}
+
+	bestT := 0.0
+	for horizon := shortest; horizon <= len(returns); horizon++ {
+		if t := tValue(returns[:horizon]); math.Abs(t) > math.Abs(bestT) {
+			bestT = t
+		}
+	}
+
+	switch {
+	case math.Abs(bestT) < flatTValue:
+		return Flat, bestT, startTime, true
+	case bestT > 0:
+		return Up, bestT, startTime, true
+	default:
+		return Down, bestT, startTime, true
+	}
+}
+
+func Evaluate(symbol string, interval int64, events []*algo.Event, timeout int64, model benchmark.ReturnModel) *TrendMetrics {
+
+	collection := db.GetCandles(interval, candlestick.Interval1d, symbol)
+
+	m := newTrendMetrics()
+	earningsWindow := config.GetEarningsWindow()
+
+	for _, event := range events {
+		label, t, entryTime, ok := findTrend(event, timeout, interval, collection, model)
+		if !ok {
+			m.Undefined++
+			continue
+		}
+		m.count(label, t)
+		for dimension, key := range calendar.EventPeriods(event.Time, entryTime, earningsWindow) {
+			countIn(m.breakdown(dimension), key, label, t)
+		}
+		countIn(m.ByRegime, regime.Default().Classify(event.Time+interval), label, t)
+	}
+
+	return m
+}
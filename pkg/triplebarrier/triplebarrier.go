@@ -5,9 +5,12 @@ import (
 	"github.com/godoji/algocore/pkg/algo"
 	"github.com/northberg/candlestick"
 	"math"
+	"pattern-evaluator/pkg/benchmark"
+	"pattern-evaluator/pkg/calendar"
+	"pattern-evaluator/pkg/config"
 	"pattern-evaluator/pkg/db"
 	"pattern-evaluator/pkg/evaluate"
-	"time"
+	"pattern-evaluator/pkg/regime"
 )
 
 type BarrierEvent int
@@ -19,54 +22,104 @@ const (
 	Undefined
 )
 
+func (e BarrierEvent) String() string {
+	switch e {
+	case UpperHit:
+		return "upper"
+	case LowerHit:
+		return "lower"
+	case TimeLimit:
+		return "timeout"
+	default:
+		return "undefined"
+	}
+}
+
 type BarrierMetrics struct {
-	Events       map[BarrierEvent]int
-	EventsByYear map[int]map[BarrierEvent]int
-	SumReturn    float64
-	SumTime      int64
+	Events           map[BarrierEvent]int
+	EventsByYear     map[int]map[BarrierEvent]int
+	EventsByQuarter  map[int]map[BarrierEvent]int
+	EventsByMonth    map[int]map[BarrierEvent]int
+	EventsByWeekday  map[int]map[BarrierEvent]int
+	EventsByEarnings map[int]map[BarrierEvent]int
+	EventsByRegime   map[string]map[BarrierEvent]int
+	SumReturn        float64
+	SumTime          int64
+}
+
+func newBarrierMetrics() *BarrierMetrics {
+	return &BarrierMetrics{
+		Events:           make(map[BarrierEvent]int),
+		EventsByYear:     make(map[int]map[BarrierEvent]int),
+		EventsByQuarter:  make(map[int]map[BarrierEvent]int),
+		EventsByMonth:    make(map[int]map[BarrierEvent]int),
+		EventsByWeekday:  make(map[int]map[BarrierEvent]int),
+		EventsByEarnings: make(map[int]map[BarrierEvent]int),
+		EventsByRegime:   make(map[string]map[BarrierEvent]int),
+	}
 }
 
 type Evaluator struct {
+	Returns benchmark.ReturnModel
 }
 
 func (e *Evaluator) Evaluate(params *evaluate.ParamSet, symbol string, events []*algo.Event) evaluate.Metrics {
-	return Evaluate(symbol, candlestick.Interval1d, events, params.Threshold, params.Timeout)
+	return Evaluate(symbol, candlestick.Interval1d, events, params.Threshold, params.Timeout, e.Returns)
 }
 
 func (bm BarrierMetrics) Combine(other evaluate.Metrics) evaluate.Metrics {
-	combined := BarrierMetrics{
-		Events:       make(map[BarrierEvent]int),
-		EventsByYear: make(map[int]map[BarrierEvent]int),
-	}
+	combined := newBarrierMetrics()
 
 	for event, count := range bm.Events {
 		combined.Events[event] = count
 	}
-	for year, metrics := range bm.EventsByYear {
-		combined.EventsByYear[year] = make(map[BarrierEvent]int)
-		for e, i := range metrics {
-			combined.EventsByYear[year][e] = i
-		}
-	}
+	bm.addBreakdowns(combined)
 
-	// now add the other BarrierMetrics
+	// now add the other BarrierMetrics, which may still be a pointer when it comes straight from Evaluate
+	if m, ok := other.(*BarrierMetrics); ok {
+		other = *m
+	}
 	if otherMetrics, ok := other.(BarrierMetrics); ok {
 		for event, count := range otherMetrics.Events {
 			combined.Events[event] += count
 		}
-		for year, metrics := range otherMetrics.EventsByYear {
-			if _, ok := combined.EventsByYear[year]; !ok {
-				combined.EventsByYear[year] = make(map[BarrierEvent]int)
-			}
-			for e, i := range metrics {
-				combined.EventsByYear[year][e] += i
-			}
-		}
+		otherMetrics.addBreakdowns(combined)
 	} else {
 		panic("cannot not add other type than BarrierMetrics")
 	}
 
-	return combined
+	return *combined
+}
+
+func (bm BarrierMetrics) addBreakdowns(dst *BarrierMetrics) {
+	for _, dimension := range calendar.Dimensions() {
+		evaluate.AddBreakdown(dst.breakdown(dimension), bm.breakdown(dimension), evaluate.AddCounts[BarrierEvent])
+	}
+	evaluate.AddBreakdown(dst.EventsByRegime, bm.EventsByRegime, evaluate.AddCounts[BarrierEvent])
+}
+
+// fromEvents holds the counts of a single period or regime
+func fromEvents(events map[BarrierEvent]int) evaluate.Metrics {
+	m := newBarrierMetrics()
+	for e, i := range events {
+		m.Events[e] = i
+	}
+	return *m
+}
+
+func (bm BarrierMetrics) ForRegime(r string) evaluate.Metrics {
+	m := fromEvents(bm.EventsByRegime[r]).(BarrierMetrics)
+	m.EventsByRegime[r] = m.Events
+	return m
+}
+
+func (bm BarrierMetrics) breakdown(dimension string) map[int]map[BarrierEvent]int {
+	return calendar.Pick(dimension, bm.EventsByYear, bm.EventsByQuarter, bm.EventsByMonth, bm.EventsByWeekday, bm.EventsByEarnings)
+}
+
+// ByPeriod splits the metrics into the metrics of every period of a calendar dimension
+func (bm BarrierMetrics) ByPeriod(dimension string) map[int]evaluate.Metrics {
+	return evaluate.SplitBreakdown(bm.breakdown(dimension), fromEvents)
 }
 
 func (bm BarrierMetrics) Evaluator() string {
@@ -89,6 +142,10 @@ func (bm BarrierMetrics) Timeouts() int {
 	return bm.Events[TimeLimit]
 }
 
+func (bm BarrierMetrics) Outcomes() (int, int) {
+	return bm.UpTrends(), bm.DownTrends()
+}
+
 func (bm BarrierMetrics) Value() float64 {
 	return evaluate.Performance(bm.UpTrends(), bm.DownTrends())
 }
@@ -112,22 +169,15 @@ func (bm BarrierMetrics) Emit(key string) float64 {
 	}
 }
 
-func findOutcome(event *algo.Event, threshold float64, timeLimit int64, interval int64, collection []*candlestick.CandleSet) (BarrierEvent, float64, int64) {
+func findOutcome(event *algo.Event, threshold float64, timeLimit int64, interval int64, collection []*candlestick.CandleSet, model benchmark.ReturnModel) (BarrierEvent, float64, int64, int64) {
 
 	// First point in time, where we have knowledge of the event
 	bookTime := event.Time + interval
 
 	// Find the candle at the start time, or after the start time if no candle was available
-	var startCandle *candlestick.Candle
-	for i := int64(0); i < 10; i++ {
-		entryCandle := db.CandleAtTimestamp(bookTime+i*interval, collection)
-		if entryCandle != nil {
-			startCandle = entryCandle
-			break
-		}
-	}
+	startCandle := db.EntryCandle(event.Time, interval, collection)
 	if startCandle == nil || startCandle.Open == 0.0 {
-		return Undefined, 0, 0
+		return Undefined, 0, 0, bookTime
 	}
 
 	// The entry price of our trade would be at the opening of the start candle
@@ -136,13 +186,16 @@ func findOutcome(event *algo.Event, threshold float64, timeLimit int64, interval
 	// The time at which we entered the trade
 	startTime := startCandle.Time
 
-	// Determine the upper and lower barrier
-	upperBarrier := entryPrice * (1.0 + threshold)
-	lowerBarrier := entryPrice * (1.0 - threshold)
+	// The part of the return we expect from the benchmark alone, zero when measuring raw returns
+	path, ok := benchmark.NewPath(model, startTime, interval, collection)
+	if !ok {
+		return Undefined, 0, 0, bookTime
+	}
 
 	// Make sure that we don't hit the time limit because of a missing candle, we still accept an exit at the first available
 	missing := 0
 	lastCandle := startCandle
+	lastExpected := 0.0
 
 	// Keep iterating candles till we either hit a barrier, or reach the time limit in candles, starting from the opening candle
 	for i := int64(0); i < timeLimit || (missing > 1 && missing < 5); i++ {
@@ -157,50 +210,53 @@ func findOutcome(event *algo.Event, threshold float64, timeLimit int64, interval
 		}
 
 		lastCandle = currentCandle
-		low := currentCandle.Low
-		high := currentCandle.High
+		lastExpected = path.Return(currentCandle.Time)
 
-		if low <= lowerBarrier {
-			profit := (lowerBarrier - startCandle.Open) / startCandle.Open
-			return LowerHit, profit, i
+		// The barriers are placed on the excess return path, which is the raw path when there is no benchmark
+		low := (currentCandle.Low-entryPrice)/entryPrice - lastExpected
+		high := (currentCandle.High-entryPrice)/entryPrice - lastExpected
+
+		if low <= -threshold {
+			return LowerHit, -threshold, i, startTime
 		}
-		if high >= upperBarrier {
-			profit := (upperBarrier - startCandle.Open) / startCandle.Open
-			return UpperHit, profit, i
+		if high >= threshold {
+			return UpperHit, threshold, i, startTime
 		}
 	}
 
-	profit := (lastCandle.Close - startCandle.Open) / startCandle.Open
-	return TimeLimit, profit, timeLimit
+	profit := (lastCandle.Close-entryPrice)/entryPrice - lastExpected
+	return TimeLimit, profit, timeLimit, startTime
+}
+
+// Label finds the barrier hit by a trade on the event, along with its return and the number of candles it was held
+func Label(event *algo.Event, threshold float64, timeout int64, interval int64, collection []*candlestick.CandleSet, model benchmark.ReturnModel) (BarrierEvent, float64, int64) {
+	result, profit, elapsed, _ := findOutcome(event, threshold, timeout, interval, collection, model)
+	return result, profit, elapsed
 }
 
-func Evaluate(symbol string, interval int64, events []*algo.Event, threshold float64, timeout int64) *BarrierMetrics {
+func Evaluate(symbol string, interval int64, events []*algo.Event, threshold float64, timeout int64, model benchmark.ReturnModel) *BarrierMetrics {
 
 	// Retrieve a list of all candles for a given symbol, adjusted for splits
 	collection := db.GetCandles(interval, candlestick.Interval1d, symbol)
 
 	// Create the mapping in which we will store our barrier hits
-	m := &BarrierMetrics{
-		Events:       make(map[BarrierEvent]int),
-		EventsByYear: make(map[int]map[BarrierEvent]int),
-		SumReturn:    0,
-		SumTime:      0,
-	}
+	m := newBarrierMetrics()
+	earningsWindow := config.GetEarningsWindow()
 
 	// Iterate all events after which we expect effect
 	for _, event := range events {
-		year := time.Unix(event.Time, 0).UTC().Year()
-		result, profit, elapsed := findOutcome(event, threshold, timeout, interval, collection)
+		r := regime.Default().Classify(event.Time + interval)
+		result, profit, elapsed, entryTime := findOutcome(event, threshold, timeout, interval, collection, model)
 		if math.IsNaN(profit) {
 			panic("profit cannot be nan")
 		}
 		m.Events[result]++
 		m.SumReturn += profit
 		m.SumTime += elapsed
-		if _, ok := m.EventsByYear[year]; !ok {
-			m.EventsByYear[year] = make(map[BarrierEvent]int)
+		for dimension, key := range calendar.EventPeriods(event.Time, entryTime, earningsWindow) {
+			evaluate.CountIn(m.breakdown(dimension), key, result)
 		}
-		m.EventsByYear[year][result]++
+		evaluate.CountIn(m.EventsByRegime, r, result)
 	}
 
 	return m